@@ -5,24 +5,167 @@ package vcstoics
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Alarm actions of RFC 5545
+const (
+	ActionAudio   = "AUDIO"
+	ActionDisplay = "DISPLAY"
+	ActionEmail   = "EMAIL"
+)
+
+// RelatedEnd makes the trigger of an alarm relative to DTEND or DUE
+const RelatedEnd = "END"
+
 // Alarm represents a calendar alarm
 type Alarm struct {
-	Difference int64 // Difference in seconds
+	Difference int64  // Difference in seconds
+	Related    string // RelatedEnd for triggers relative to the end or DUE
+	At         string // UTC date-time of absolute triggers, used over Difference
+
+	Action      string        // ActionAudio, ActionDisplay or ActionEmail
+	RunTime     string        // date-time the alarm goes off, as found in the vCalendar
+	Snooze      time.Duration // time between repetitions
+	Repeat      int           // number of repetitions after the first alarm
+	Description string        // display string or mail note, the summary if empty
+	Attach      string        // audio content of audio alarms
+	Email       string        // address of mail alarms
+	Procedure   string        // procedure of PALARM, which cannot be converted
 }
 
 // NewAlarm creates a new alarm with start time and alarm time
 func NewAlarm(start, alarmTime time.Time) *Alarm {
 	return &Alarm{
+		Action:     ActionDisplay,
 		Difference: int64(alarmTime.Sub(start).Seconds()),
 	}
 }
 
+// ParseAlarm parses the AALARM, DALARM, MALARM or PALARM property of a
+// vCalendar entry. Their values are lists of a run time, snooze time,
+// repeat count and a type specific part: audio content, display string,
+// mail address and note, or procedure name. Procedure alarms become
+// display alarms as RFC 5545 has no counterpart.
+func ParseAlarm(p *Property) (*Alarm, error) {
+	fields := splitValue(p.Value)
+	field := func(i int) string {
+		if i < len(fields) {
+			return UnescapeText(strings.TrimSpace(fields[i]))
+		}
+		return ""
+	}
+
+	a := &Alarm{RunTime: field(0)}
+	if a.RunTime == "" {
+		return nil, fmt.Errorf("%s has no run time", p.Name)
+	}
+
+	if snooze := field(1); snooze != "" {
+		d, err := parseISODuration(snooze)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snooze time in %s: %w", p.Name, err)
+		}
+		a.Snooze = d
+	}
+	if repeat := field(2); repeat != "" {
+		n, err := strconv.Atoi(repeat)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid repeat count in %s: %s", p.Name, repeat)
+		}
+		a.Repeat = n
+	}
+
+	switch p.Name {
+	case "AALARM":
+		a.Action = ActionAudio
+		a.Attach = field(3)
+	case "DALARM":
+		a.Action = ActionDisplay
+		a.Description = field(3)
+	case "MALARM":
+		a.Action = ActionEmail
+		a.Email = field(3)
+		a.Description = field(4)
+	case "PALARM":
+		a.Action = ActionDisplay
+		a.Procedure = field(3)
+	default:
+		return nil, fmt.Errorf("%s is not an alarm", p.Name)
+	}
+
+	return a, nil
+}
+
+// isAlarm reports whether a vCalendar property is an alarm
+func isAlarm(name string) bool {
+	switch name {
+	case "AALARM", "DALARM", "MALARM", "PALARM":
+		return true
+	}
+	return false
+}
+
+// parseISODuration parses durations such as PT5M, P1D or -PT1H30M
+func parseISODuration(s string) (time.Duration, error) {
+	rest := strings.ToUpper(s)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		sign, rest = -1, rest[1:]
+	case strings.HasPrefix(rest, "+"):
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	rest = rest[1:]
+
+	var d time.Duration
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		unit := time.Duration(n)
+
+		switch {
+		case rest[i] == 'W' && !inTime:
+			d += unit * 7 * 24 * time.Hour
+		case rest[i] == 'D' && !inTime:
+			d += unit * 24 * time.Hour
+		case rest[i] == 'H' && inTime:
+			d += unit * time.Hour
+		case rest[i] == 'M' && inTime:
+			d += unit * time.Minute
+		case rest[i] == 'S' && inTime:
+			d += unit * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		rest = rest[i+1:]
+	}
+
+	return sign * d, nil
+}
+
 // parseDuration converts the time difference to ICS duration format
 func (a *Alarm) parseDuration() string {
+	return formatDuration(a.Difference)
+}
+
+// formatDuration formats a number of seconds as an ICS duration
+func formatDuration(seconds int64) string {
 	// Constants for duration parts
 	const (
 		minLength  = 60
@@ -31,8 +174,11 @@ func (a *Alarm) parseDuration() string {
 		weekLength = dayLength * 7
 	)
 
+	if seconds == 0 {
+		return "PT0S"
+	}
+
 	var sb strings.Builder
-	seconds := a.Difference
 
 	if seconds > 0 {
 		sb.WriteString("P")
@@ -73,13 +219,82 @@ func (a *Alarm) parseDuration() string {
 	return sb.String()
 }
 
-// ToICS converts an Alarm to ICS format string
-func (a *Alarm) ToICS(description string) string {
+// ToICS converts an Alarm to ICS format string. The summary of the entry
+// is used when the alarm has no description of its own.
+func (a *Alarm) ToICS(summary string) string {
+	action := a.Action
+	if action == "" {
+		action = ActionDisplay
+	}
+
+	description := a.Description
+	if description == "" {
+		description = summary
+	}
+
 	var sb strings.Builder
 	sb.WriteString("BEGIN:VALARM" + newLine)
-	sb.WriteString("ACTION:DISPLAY" + newLine)
-	sb.WriteString("DESCRIPTION:" + description + newLine)
-	sb.WriteString("TRIGGER:" + a.parseDuration() + newLine)
+	sb.WriteString("ACTION:" + action + newLine)
+
+	switch action {
+	case ActionAudio:
+		// ATTACH takes a URI, a bare sound name is kept aside
+		switch {
+		case strings.Contains(a.Attach, ":"):
+			sb.WriteString("ATTACH:" + a.Attach + newLine)
+		case a.Attach != "":
+			sb.WriteString("X-VCS-ATTACH:" + EscapeText(a.Attach) + newLine)
+		}
+	case ActionEmail:
+		sb.WriteString("DESCRIPTION:" + EscapeText(description) + newLine)
+		sb.WriteString("SUMMARY:" + EscapeText(summary) + newLine)
+		if a.Email != "" {
+			sb.WriteString("ATTENDEE:" + mailto(a.Email) + newLine)
+		}
+	default:
+		sb.WriteString("DESCRIPTION:" + EscapeText(description) + newLine)
+	}
+
+	switch {
+	case a.At != "":
+		sb.WriteString("TRIGGER;VALUE=DATE-TIME:" + a.At + newLine)
+	case a.Related == RelatedEnd:
+		sb.WriteString("TRIGGER;RELATED=END:" + a.parseDuration() + newLine)
+	default:
+		sb.WriteString("TRIGGER:" + a.parseDuration() + newLine)
+	}
+
+	// RFC 5545 wants both or neither
+	if a.Repeat > 0 && a.Snooze > 0 {
+		sb.WriteString("DURATION:" + formatDuration(int64(a.Snooze.Seconds())) + newLine)
+		sb.WriteString("REPEAT:" + strconv.Itoa(a.Repeat) + newLine)
+	}
+
 	sb.WriteString("END:VALARM")
 	return sb.String()
 }
+
+// mailto turns an email address into a CAL-ADDRESS
+func mailto(address string) string {
+	if strings.HasPrefix(strings.ToLower(address), "mailto:") {
+		return address
+	}
+	return "mailto:" + address
+}
+
+// trigger returns the seconds from the anchor to the run time of an
+// alarm. Run times are converted to the time the anchor is expressed in.
+func (st startType) trigger(runTime string) (int64, error) {
+	values, err := parseDateList(runTime)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, fmt.Errorf("invalid run time %q", runTime)
+	}
+
+	// The alarm of an all-day entry keeps its time of day
+	timed := st
+	timed.allDay = false
+	return int64(timed.wall(values[0]).Sub(st.start).Seconds()), nil
+}
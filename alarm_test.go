@@ -0,0 +1,161 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"testing"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestParseAlarm(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  vcstoics.Alarm
+	}{
+		{
+			"AALARM", "20110608T054500Z;PT5M;3;beep.wav",
+			vcstoics.Alarm{Action: vcstoics.ActionAudio, RunTime: "20110608T054500Z", Snooze: 5 * time.Minute, Repeat: 3, Attach: "beep.wav"},
+		},
+		{
+			"AALARM", "20110608T054500Z;;;",
+			vcstoics.Alarm{Action: vcstoics.ActionAudio, RunTime: "20110608T054500Z"},
+		},
+		{
+			"DALARM", "20110608T054500;PT1H;2;Call Bob\\; then leave",
+			vcstoics.Alarm{Action: vcstoics.ActionDisplay, RunTime: "20110608T054500", Snooze: time.Hour, Repeat: 2, Description: "Call Bob; then leave"},
+		},
+		{
+			"MALARM", "20110608T054500Z;;;bob@example.com;Bring the slides",
+			vcstoics.Alarm{Action: vcstoics.ActionEmail, RunTime: "20110608T054500Z", Email: "bob@example.com", Description: "Bring the slides"},
+		},
+		{
+			"PALARM", "20110608T054500Z;P1DT2H;1;c:\\\\bin\\\\alarm.exe",
+			vcstoics.Alarm{Action: vcstoics.ActionDisplay, RunTime: "20110608T054500Z", Snooze: 26 * time.Hour, Repeat: 1, Procedure: "c:\\bin\\alarm.exe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.value, func(t *testing.T) {
+			got, err := vcstoics.ParseAlarm(&vcstoics.Property{Name: tt.name, Value: tt.value})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", ";PT5M", "20110608T054500Z;5 minutes", "20110608T054500Z;;twice"} {
+		if _, err := vcstoics.ParseAlarm(&vcstoics.Property{Name: "DALARM", Value: value}); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestConvertAlarms(t *testing.T) {
+	tests := []struct {
+		name  string
+		alarm string
+		want  string
+	}{
+		{
+			"audio with snooze", "AALARM;TYPE=X-EPOCSOUND:20110608T054500Z;PT5M;3;beep.wav",
+			"BEGIN:VALARM\nACTION:AUDIO\nX-VCS-ATTACH:beep.wav\nTRIGGER:-PT15M\nDURATION:PT5M\nREPEAT:3\nEND:VALARM\n",
+		},
+		{
+			"audio uri", "AALARM:20110608T054500Z;;;file:///sounds/beep.wav",
+			"BEGIN:VALARM\nACTION:AUDIO\nATTACH:file:///sounds/beep.wav\nTRIGGER:-PT15M\nEND:VALARM\n",
+		},
+		{
+			"display string", "DALARM:20110608T055000Z;;;Leave now",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Leave now\nTRIGGER:-PT10M\nEND:VALARM\n",
+		},
+		{
+			"display without string", "DALARM:20110608T055000Z",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Meeting\nTRIGGER:-PT10M\nEND:VALARM\n",
+		},
+		{
+			"local run time", "DALARM:20110608T073000",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Meeting\nTRIGGER:-PT30M\nEND:VALARM\n",
+		},
+		{
+			"mail", "MALARM:20110608T050000Z;;;bob@example.com;Bring the slides",
+			"BEGIN:VALARM\nACTION:EMAIL\nDESCRIPTION:Bring the slides\nSUMMARY:Meeting\nATTENDEE:mailto:bob@example.com\nTRIGGER:-PT1H\nEND:VALARM\n",
+		},
+		{
+			"repeat without snooze", "DALARM:20110608T055000Z;;3;Leave now",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Leave now\nTRIGGER:-PT10M\nEND:VALARM\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nTZ:+02\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Meeting\r\n" +
+				"DTSTART:20110608T060000Z\r\nDTEND:20110608T070000Z\r\n" + tt.alarm + "\r\n" +
+				"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			if got := components(convertString(t, input, vcstoics.Options{}), "VALARM"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertProcedureAlarm(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Backup\r\n" +
+		"DTSTART:20110608T060000Z\r\nPALARM:20110608T060000Z;;;backup.exe\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	var warnings int
+	got := components(convertString(t, input, vcstoics.Options{
+		Warnf: func(string, ...any) { warnings++ },
+	}), "VALARM")
+
+	want := "BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Backup\nTRIGGER:PT0S\nEND:VALARM\n"
+	if got != want || warnings != 1 {
+		t.Errorf("got %d warnings and:\n%s\nwant one warning and:\n%s", warnings, got, want)
+	}
+}
+
+func TestConvertMultipleAlarms(t *testing.T) {
+	tests := []struct {
+		name  string
+		comp  string
+		props string
+		want  string
+	}{
+		{
+			"event", "VEVENT",
+			"DTSTART:20110608T060000Z\r\nAALARM:20110608T054500Z\r\nDALARM:20110608T055500Z;;;Soon\r\n",
+			"BEGIN:VALARM\nACTION:AUDIO\nTRIGGER:-PT15M\nEND:VALARM\n" +
+				"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Soon\nTRIGGER:-PT5M\nEND:VALARM\n",
+		},
+		{
+			"todo with due", "VTODO",
+			"DUE:20110608T120000Z\r\nDALARM:20110608T110000Z\r\nMALARM:20110607T120000Z;;;bob@example.com\r\n",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Meeting\nTRIGGER;RELATED=END:-PT1H\nEND:VALARM\n" +
+				"BEGIN:VALARM\nACTION:EMAIL\nDESCRIPTION:Meeting\nSUMMARY:Meeting\nATTENDEE:mailto:bob@example.com\nTRIGGER;RELATED=END:-P1D\nEND:VALARM\n",
+		},
+		{
+			"todo without due", "VTODO",
+			"DALARM:20110608T110000\r\n",
+			"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Meeting\nTRIGGER;VALUE=DATE-TIME:20110608T090000Z\nEND:VALARM\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nTZ:+02\r\nBEGIN:" + tt.comp + "\r\nUID:1\r\nSUMMARY:Meeting\r\n" + tt.props +
+				"END:" + tt.comp + "\r\nEND:VCALENDAR\r\n"
+
+			if got := components(convertString(t, input, vcstoics.Options{}), "VALARM"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
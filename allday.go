@@ -0,0 +1,142 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AllDayPolicy tells which entries given with UTC times, in a calendar
+// without a time zone, are taken as all-day. Whether a UTC time is local
+// midnight depends on a zone that is not known then.
+type AllDayPolicy int
+
+// AllDayPolicy constants
+const (
+	// AllDayUTC takes UTC midnight for local midnight
+	AllDayUTC AllDayPolicy = iota
+
+	// AllDayStrict never takes UTC times for all-day entries
+	AllDayStrict
+
+	// AllDayGuessZone takes spans of whole days starting on the hour for
+	// all-day entries in the zone where that hour is midnight, as exported
+	// by phones that are not set to UTC
+	AllDayGuessZone
+)
+
+// ParseAllDayPolicy parses the name of a policy: utc, strict or guess
+func ParseAllDayPolicy(s string) (AllDayPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "utc":
+		return AllDayUTC, nil
+	case "strict":
+		return AllDayStrict, nil
+	case "guess":
+		return AllDayGuessZone, nil
+	}
+	return AllDayUTC, fmt.Errorf("unknown all-day policy %q: expected utc, strict or guess", s)
+}
+
+// allDaySpan holds the dates of an all-day entry
+type allDaySpan struct {
+	start time.Time // first day
+	days  int
+}
+
+// end returns the day after the last day, the exclusive DTEND
+func (s allDaySpan) end() time.Time {
+	return s.start.AddDate(0, 0, s.days)
+}
+
+// allDaySpan tells whether e is an all-day entry and which days it takes.
+// Entries are all-day when they run from midnight to midnight, N days
+// later or at the same time, in their local time. tz is the time zone
+// local times refer to, if known.
+func (e *Entry) allDaySpan(tz *TimeZone, policy AllDayPolicy) (allDaySpan, bool) {
+	start, utc, err := localTime(e.DTStart, tz)
+	if err != nil {
+		return allDaySpan{}, false
+	}
+	if e.AllDay {
+		end, _, err := localTime(e.DTEnd, tz)
+		if err != nil || end.Before(start) {
+			end = start
+		}
+		return spanOf(start, end), true
+	}
+
+	end, _, err := localTime(e.DTEnd, tz)
+	if err != nil || end.Before(start) {
+		return allDaySpan{}, false
+	}
+
+	ambiguous := utc && tz == nil
+	switch {
+	case ambiguous && policy == AllDayStrict:
+		return allDaySpan{}, false
+	case ambiguous && policy == AllDayGuessZone && !isMidnight(start):
+		offset, ok := midnightOffset(start, end)
+		if !ok {
+			return allDaySpan{}, false
+		}
+		start, end = start.Add(offset), end.Add(offset)
+	}
+
+	if !isMidnight(start) || !isMidnight(end) {
+		return allDaySpan{}, false
+	}
+	return spanOf(start, end), true
+}
+
+// localTime parses a date-time into the local wall clock time of an entry.
+// UTC times are converted when tz is known. It reports whether the value
+// is in UTC.
+func localTime(value string, tz *TimeZone) (time.Time, bool, error) {
+	t, err := ParseDate(value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	utc := strings.HasSuffix(value, "Z")
+	if utc && tz != nil {
+		t = t.Add(tz.OffsetAt(t))
+	}
+	return t, utc, nil
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Equal(dateOf(t))
+}
+
+// midnightOffset returns the UTC offset in which a span of whole days
+// starting on the hour runs from midnight to midnight
+func midnightOffset(start, end time.Time) (time.Duration, bool) {
+	span := end.Sub(start)
+	if start.Minute() != 0 || start.Second() != 0 || span < 24*time.Hour || span%(24*time.Hour) != 0 {
+		return 0, false
+	}
+
+	offset := time.Duration(24-start.Hour()) * time.Hour
+	if offset > 14*time.Hour {
+		offset -= 24 * time.Hour
+	}
+	return offset, true
+}
+
+// spanOf returns the days from start to end. The end is exclusive, but an
+// end later in the day takes that day too.
+func spanOf(start, end time.Time) allDaySpan {
+	first, last := dateOf(start), dateOf(end)
+	if !end.Equal(last) {
+		last = last.AddDate(0, 0, 1)
+	}
+
+	days := 0
+	for d := first; d.Before(last); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return allDaySpan{start: first, days: max(days, 1)}
+}
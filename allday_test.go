@@ -0,0 +1,103 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestConvertAllDay(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		times   string
+		policy  string
+		want    string // DTSTART and DTEND lines
+	}{
+		{
+			"same start and end", "", "DTSTART:20110608T000000\r\nDTEND:20110608T000000\r\n", "",
+			"DTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"one day", "", "DTSTART:20110608T000000\r\nDTEND:20110609T000000\r\n", "",
+			"DTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"three days", "", "DTSTART:20110608T000000\r\nDTEND:20110611T000000\r\n", "",
+			"DTSTART;VALUE=DATE:20110608\nDTEND;VALUE=DATE:20110611\n",
+		},
+		{
+			"midnight to noon", "", "DTSTART:20110608T000000\r\nDTEND:20110608T120000\r\n", "",
+			"DTSTART:20110608T000000\nDTEND:20110608T120000\n",
+		},
+		{
+			"utc in a known zone", "TZ:+02\r\n", "DTSTART:20110607T220000Z\r\nDTEND:20110609T220000Z\r\n", "strict",
+			"DTSTART;VALUE=DATE:20110608\nDTEND;VALUE=DATE:20110610\n",
+		},
+		{
+			"utc midnight in a known zone", "TZ:+02\r\n", "DTSTART:20110608T000000Z\r\nDTEND:20110609T000000Z\r\n", "guess",
+			"DTSTART:20110608T000000Z\nDTEND:20110609T000000Z\n",
+		},
+		{
+			"utc midnight", "", "DTSTART:20110608T000000Z\r\nDTEND:20110609T000000Z\r\n", "utc",
+			"DTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"utc midnight strict", "", "DTSTART:20110608T000000Z\r\nDTEND:20110609T000000Z\r\n", "strict",
+			"DTSTART:20110608T000000Z\nDTEND:20110609T000000Z\n",
+		},
+		{
+			"utc whole days", "", "DTSTART:20110607T220000Z\r\nDTEND:20110608T220000Z\r\n", "utc",
+			"DTSTART:20110607T220000Z\nDTEND:20110608T220000Z\n",
+		},
+		{
+			"utc whole days guessed", "", "DTSTART:20110607T220000Z\r\nDTEND:20110608T220000Z\r\n", "guess",
+			"DTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"utc west of greenwich guessed", "", "DTSTART:20110608T050000Z\r\nDTEND:20110610T050000Z\r\n", "guess",
+			"DTSTART;VALUE=DATE:20110608\nDTEND;VALUE=DATE:20110610\n",
+		},
+		{
+			"utc hours guessed", "", "DTSTART:20110607T220000Z\r\nDTEND:20110608T020000Z\r\n", "guess",
+			"DTSTART:20110607T220000Z\nDTEND:20110608T020000Z\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := vcstoics.ParseAllDayPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			input := "BEGIN:VCALENDAR\r\n" + tt.headers + "BEGIN:VEVENT\r\nUID:1\r\n" + tt.times +
+				"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			entries := convertString(t, input, vcstoics.Options{AllDay: policy})
+			if got := propertyLines(entries, "DTSTART", "DTEND"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := vcstoics.ParseAllDayPolicy("sometimes"); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
+
+func TestConvertAllDayRecurrence(t *testing.T) {
+	// Exceptions given in UTC fall on the local dates of the entry
+	input := "BEGIN:VCALENDAR\r\nTZ:+02\r\nBEGIN:VEVENT\r\nUID:1\r\n" +
+		"DTSTART:20110607T220000Z\r\nDTEND:20110608T220000Z\r\nRRULE:D1 #5\r\nEXDATE:20110608T220000Z\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	got := propertyLines(convertString(t, input, vcstoics.Options{}), "RRULE", "EXDATE", "DTSTART", "DTEND")
+	want := "RRULE:FREQ=DAILY;INTERVAL=1;COUNT=5\nEXDATE;VALUE=DATE:20110609\nDTSTART;VALUE=DATE:20110608\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment is a document attached to an entry, either inline or by
+// reference
+type Attachment struct {
+	URI     string // reference to the attachment, empty for inline data
+	Data    []byte // inline content
+	FmtType string // media type, e.g. image/jpeg, if known
+}
+
+// attachmentTypes maps the TYPE values vCalendar uses for attachments to
+// media types, and media types to the extension of extracted files
+var attachmentTypes = []struct {
+	vcal, mime, ext string
+}{
+	{"JPEG", "image/jpeg", ".jpg"},
+	{"GIF", "image/gif", ".gif"},
+	{"PNG", "image/png", ".png"},
+	{"BMP", "image/bmp", ".bmp"},
+	{"TIFF", "image/tiff", ".tiff"},
+	{"PDF", "application/pdf", ".pdf"},
+	{"WAVE", "audio/wav", ".wav"},
+	{"AIFF", "audio/aiff", ".aiff"},
+	{"MPEG", "video/mpeg", ".mpeg"},
+	{"QTIME", "video/quicktime", ".mov"},
+	{"VCARD", "text/x-vcard", ".vcf"},
+	{"TEXT", "text/plain", ".txt"},
+	{"HTML", "text/html", ".html"},
+}
+
+// ParseAttachment parses an ATTACH property. Values encoded with BASE64 or
+// QUOTED-PRINTABLE are inline data, as are values given as VALUE=INLINE.
+// Anything else is a reference: a URL or a content ID.
+func ParseAttachment(p *Property) (*Attachment, error) {
+	a := &Attachment{FmtType: attachmentType(p.Params)}
+
+	value := strings.TrimSpace(p.Value)
+	switch kind := strings.ToUpper(p.Params.Get("VALUE")); {
+	case kind == "CONTENT-ID" || kind == "CID":
+		a.URI = value
+		if !strings.HasPrefix(strings.ToLower(value), "cid:") {
+			a.URI = "cid:" + strings.Trim(value, "<>")
+		}
+	case kind == "URL" || kind == "URI":
+		a.URI = value
+	case kind == "INLINE" || kind == "BINARY" || isEncoded(p.Params):
+		switch strings.ToUpper(p.Params.Get("ENCODING")) {
+		case "BASE64", "B":
+			// Decoded again, as a CHARSET would have converted the bytes
+			data, err := decodeBase64(p.Raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BASE64 data in ATTACH: %w", err)
+			}
+			a.Data = data
+		default:
+			a.Data = []byte(p.Value)
+		}
+	case strings.Contains(value, ":"):
+		a.URI = value
+	default:
+		a.Data = []byte(p.Value)
+	}
+
+	if a.URI == "" && len(a.Data) == 0 {
+		return nil, fmt.Errorf("empty ATTACH")
+	}
+	if a.FmtType == "" && len(a.Data) > 0 {
+		if sniffed := http.DetectContentType(a.Data); sniffed != "application/octet-stream" {
+			a.FmtType, _, _ = strings.Cut(sniffed, ";")
+		}
+	}
+
+	return a, nil
+}
+
+// attachmentType returns the media type given by the FMTTYPE or TYPE
+// parameters of an attachment
+func attachmentType(params Params) string {
+	if fmtType := params.Get("FMTTYPE"); fmtType != "" {
+		return strings.ToLower(fmtType)
+	}
+	for _, t := range params.Values("TYPE") {
+		if strings.Contains(t, "/") {
+			return strings.ToLower(t)
+		}
+		for _, known := range attachmentTypes {
+			if strings.EqualFold(t, known.vcal) {
+				return known.mime
+			}
+		}
+	}
+	return ""
+}
+
+// extension returns the file extension for the media type of a
+func (a *Attachment) extension() string {
+	for _, known := range attachmentTypes {
+		if known.mime == a.FmtType {
+			return known.ext
+		}
+	}
+	return ".bin"
+}
+
+// ToICS converts the attachment to an RFC 5545 ATTACH property
+func (a *Attachment) ToICS() string {
+	var sb strings.Builder
+	sb.WriteString("ATTACH")
+	if a.URI == "" {
+		sb.WriteString(";ENCODING=BASE64;VALUE=BINARY")
+	}
+	if a.FmtType != "" {
+		sb.WriteString(";FMTTYPE=" + a.FmtType)
+	}
+
+	if a.URI == "" {
+		sb.WriteString(":" + base64.StdEncoding.EncodeToString(a.Data))
+	} else {
+		sb.WriteString(":" + a.URI)
+	}
+	return sb.String()
+}
+
+// Extract writes inline data to a file in dir named after the entry and
+// returns the attachment referring to the file instead. A hash of the UID
+// keeps apart entries whose UIDs only differ in characters that are not
+// safe in file names. Attachments that are references are returned as
+// they are.
+func (a *Attachment) Extract(dir, uid string, n int) (*Attachment, error) {
+	if a.URI != "" {
+		return a, nil
+	}
+
+	sum := sha256.Sum256([]byte(uid))
+	name := fmt.Sprintf("%s-%s-%d%s", safeFileName(uid), hex.EncodeToString(sum[:4]), n, a.extension())
+	path, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to extract attachment: %w", err)
+	}
+	if err := os.WriteFile(path, a.Data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to extract attachment: %w", err)
+	}
+
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed // drive letters
+	}
+	uri := url.URL{Scheme: "file", Path: slashed}
+	return &Attachment{URI: uri.String(), FmtType: a.FmtType}, nil
+}
+
+// safeFileName replaces the characters of s that are not safe in file
+// names on common systems
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+func TestParseAttachment(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(pngData)
+
+	tests := []struct {
+		line string
+		want vcstoics.Attachment
+	}{
+		{"ATTACH;ENCODING=BASE64;VALUE=INLINE;TYPE=JPEG:" + base64.StdEncoding.EncodeToString([]byte("jpeg")), vcstoics.Attachment{Data: []byte("jpeg"), FmtType: "image/jpeg"}},
+		{"ATTACH;BASE64:" + encoded, vcstoics.Attachment{Data: pngData, FmtType: "image/png"}},
+		{"ATTACH;VALUE=URL:http://example.com/agenda.pdf", vcstoics.Attachment{URI: "http://example.com/agenda.pdf"}},
+		{"ATTACH;VALUE=CONTENT-ID:<agenda@example.com>", vcstoics.Attachment{URI: "cid:agenda@example.com"}},
+		{"ATTACH:ftp://example.com/pub/agenda.txt", vcstoics.Attachment{URI: "ftp://example.com/pub/agenda.txt"}},
+		{"ATTACH;ENCODING=QUOTED-PRINTABLE:Bring=20slides", vcstoics.Attachment{Data: []byte("Bring slides"), FmtType: "text/plain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			cal, err := vcstoics.ParseVCalendar(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + tt.line + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := vcstoics.ParseAttachment(cal.Components[0].Properties[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.URI != tt.want.URI || !bytes.Equal(got.Data, tt.want.Data) || got.FmtType != tt.want.FmtType {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// inlineAttachment is an entry with BASE64 data over lines that are not
+// indented and end with a blank line, as Sony Ericsson phones write them
+func inlineAttachment() string {
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat(pngData, 4))
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:ev/1\r\nDTSTART:20110608T060000Z\r\n" +
+		"ATTACH;ENCODING=BASE64;VALUE=INLINE;TYPE=PNG:" + encoded[:40] + "\r\n" +
+		encoded[40:80] + "\r\n " + encoded[80:] + "\r\n\r\n" +
+		"SUMMARY:After the attachment\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func TestConvertAttachment(t *testing.T) {
+	got := propertyLines(convertString(t, inlineAttachment(), vcstoics.Options{}), "ATTACH", "SUMMARY")
+	want := "SUMMARY:After the attachment\n" +
+		"ATTACH;ENCODING=BASE64;VALUE=BINARY;FMTTYPE=image/png:" + base64.StdEncoding.EncodeToString(bytes.Repeat(pngData, 4)) + "\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertExtractAttachment(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "attachments")
+
+	attach := propertyLines(convertString(t, inlineAttachment(), vcstoics.Options{AttachmentDir: dir}), "ATTACH")
+	ref, ok := strings.CutPrefix(strings.TrimSuffix(attach, "\n"), "ATTACH;FMTTYPE=image/png:")
+	if !ok {
+		t.Fatalf("got %q, want a reference to the extracted file", attach)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "file" || !strings.HasSuffix(u.Path, "/attachments/ev_1-b595afc6-1.png") {
+		t.Fatalf("unexpected reference %q", ref)
+	}
+	data, err := os.ReadFile(u.Path)
+	if err != nil {
+		t.Fatalf("attachment not extracted: %v", err)
+	}
+	if !bytes.Equal(data, bytes.Repeat(pngData, 4)) {
+		t.Errorf("extracted data differs")
+	}
+}
+
+func TestExtractAttachmentUIDs(t *testing.T) {
+	dir := t.TempDir()
+	a := &vcstoics.Attachment{Data: pngData, FmtType: "image/png"}
+
+	uris := map[string]bool{}
+	for _, uid := range []string{"ev/1", "ev:1", "ev_1"} {
+		extracted, err := a.Extract(dir, uid, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		uris[extracted.URI] = true
+	}
+	if len(uris) != 3 {
+		t.Errorf("got %d files for 3 UIDs: %v", len(uris), uris)
+	}
+}
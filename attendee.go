@@ -0,0 +1,120 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attendee is a participant of an entry, taken from a vCalendar ATTENDEE
+// or ORGANIZER property
+type Attendee struct {
+	Name   string // display name, if given
+	Email  string
+	Role   string // vCalendar ROLE: ATTENDEE, ORGANIZER, OWNER or DELEGATE
+	Status string // vCalendar STATUS, e.g. NEEDS ACTION or ACCEPTED
+	RSVP   bool
+	Expect string // vCalendar EXPECT: FYI, REQUIRE, REQUEST or IMMEDIATE
+}
+
+// ParseAttendee parses an ATTENDEE or ORGANIZER property. Values are
+// addresses like "John Smith <john@example.com>" or plain addresses,
+// optionally as a mailto: URI.
+func ParseAttendee(p *Property) (*Attendee, error) {
+	a := &Attendee{
+		Role:   strings.ToUpper(strings.TrimSpace(p.Params.Get("ROLE"))),
+		Status: strings.ToUpper(strings.TrimSpace(p.Params.Get("STATUS"))),
+		RSVP:   strings.EqualFold(strings.TrimSpace(p.Params.Get("RSVP")), "YES"),
+		Expect: strings.ToUpper(strings.TrimSpace(p.Params.Get("EXPECT"))),
+	}
+	if p.Name == "ORGANIZER" {
+		a.Role = "ORGANIZER"
+	}
+
+	value := strings.TrimSpace(UnescapeText(p.Value))
+	if open := strings.LastIndex(value, "<"); open >= 0 && strings.HasSuffix(value, ">") {
+		a.Name = strings.Trim(strings.TrimSpace(value[:open]), `"`)
+		value = strings.TrimSpace(value[open+1 : len(value)-1])
+	}
+	if len(value) > len("mailto:") && strings.EqualFold(value[:len("mailto:")], "mailto:") {
+		value = value[len("mailto:"):]
+	}
+	if cn := p.Params.Get("CN"); cn != "" && a.Name == "" {
+		a.Name = cn
+	}
+
+	if !strings.Contains(value, "@") {
+		return nil, fmt.Errorf("%s %q has no email address", p.Name, p.Value)
+	}
+	a.Email = value
+
+	return a, nil
+}
+
+// IsOrganizer reports whether the attendee organizes the entry
+func (a *Attendee) IsOrganizer() bool {
+	return a.Role == "ORGANIZER" || a.Role == "OWNER"
+}
+
+// ToICS converts the attendee to an RFC 5545 ATTENDEE of a VEVENT or
+// VTODO. It reports the vCalendar values that have no counterpart.
+func (a *Attendee) ToICS(isEvent bool) (string, []string) {
+	var sb strings.Builder
+	var unmapped []string
+
+	sb.WriteString("ATTENDEE")
+	a.writeName(&sb)
+
+	if a.Expect != "" {
+		if role, ok := expectations.lookup(a.Expect); ok {
+			sb.WriteString(";ROLE=" + role)
+		} else {
+			unmapped = append(unmapped, "EXPECT="+a.Expect)
+		}
+	}
+
+	if a.Status != "" {
+		if partStat, ok := MapPartStat(a.Status, isEvent); ok {
+			sb.WriteString(";PARTSTAT=" + partStat)
+		} else {
+			unmapped = append(unmapped, "STATUS="+a.Status)
+		}
+	}
+
+	if a.RSVP {
+		sb.WriteString(";RSVP=TRUE")
+	}
+
+	// Delegates are kept as attendees, RFC 5545 tells delegation by the
+	// DELEGATED-FROM parameter that vCalendar lacks
+	sb.WriteString(":" + mailto(a.Email))
+	return sb.String(), unmapped
+}
+
+// OrganizerToICS converts the attendee to an RFC 5545 ORGANIZER
+func (a *Attendee) OrganizerToICS() string {
+	var sb strings.Builder
+	sb.WriteString("ORGANIZER")
+	a.writeName(&sb)
+	sb.WriteString(":" + mailto(a.Email))
+	return sb.String()
+}
+
+func (a *Attendee) writeName(sb *strings.Builder) {
+	if a.Name != "" {
+		sb.WriteString(";CN=" + quoteParam(a.Name))
+	}
+}
+
+// quoteParam quotes a parameter value if it contains characters that
+// are not allowed in a bare value. Double quotes cannot be escaped and
+// are dropped.
+func quoteParam(value string) string {
+	value = strings.ReplaceAll(value, `"`, "")
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + value + `"`
+	}
+	return value
+}
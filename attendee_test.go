@@ -0,0 +1,99 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestParseAttendee(t *testing.T) {
+	tests := []struct {
+		line string
+		want vcstoics.Attendee
+	}{
+		{
+			"ATTENDEE;ROLE=OWNER;STATUS=CONFIRMED:John Smith <john@example.com>",
+			vcstoics.Attendee{Name: "John Smith", Email: "john@example.com", Role: "OWNER", Status: "CONFIRMED"},
+		},
+		{
+			"ATTENDEE;RSVP=YES;EXPECT=REQUIRE;STATUS=NEEDS ACTION:\"Doe, Jane\" <jane@example.com>",
+			vcstoics.Attendee{Name: "Doe, Jane", Email: "jane@example.com", Status: "NEEDS ACTION", RSVP: true, Expect: "REQUIRE"},
+		},
+		{
+			"ATTENDEE:bob@example.com",
+			vcstoics.Attendee{Email: "bob@example.com"},
+		},
+		{
+			"ORGANIZER;CN=Ann:mailto:ann@example.com",
+			vcstoics.Attendee{Name: "Ann", Email: "ann@example.com", Role: "ORGANIZER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			cal, err := vcstoics.ParseVCalendar(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + tt.line + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := vcstoics.ParseAttendee(cal.Components[0].Properties[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	if _, err := vcstoics.ParseAttendee(&vcstoics.Property{Name: "ATTENDEE", Value: "John Smith"}); err == nil {
+		t.Errorf("expected an error for an attendee without address")
+	}
+}
+
+func TestConvertAttendees(t *testing.T) {
+	tests := []struct {
+		name  string
+		comp  string
+		props string
+		want  string // ORGANIZER and ATTENDEE lines
+	}{
+		{
+			"owner", "VEVENT",
+			"ATTENDEE;ROLE=OWNER;STATUS=CONFIRMED:John Smith <john@example.com>\r\n" +
+				"ATTENDEE;RSVP=YES;EXPECT=REQUEST;STATUS=NEEDS ACTION:\"Doe, Jane\" <jane@example.com>\r\n" +
+				"ATTENDEE;STATUS=SENT;EXPECT=FYI:bob@example.com\r\n",
+			"ORGANIZER;CN=John Smith:mailto:john@example.com\n" +
+				"ATTENDEE;CN=\"Doe, Jane\";ROLE=OPT-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:jane@example.com\n" +
+				"ATTENDEE;ROLE=NON-PARTICIPANT;PARTSTAT=NEEDS-ACTION:mailto:bob@example.com\n",
+		},
+		{
+			"fallback organizer", "VEVENT",
+			"ATTENDEE;STATUS=DECLINED:bob@example.com\r\n",
+			"ORGANIZER:mailto:me@example.com\n" +
+				"ATTENDEE;PARTSTAT=DECLINED:mailto:bob@example.com\n",
+		},
+		{
+			"todo", "VTODO",
+			"ATTENDEE;ROLE=ORGANIZER:ann@example.com\r\nATTENDEE;STATUS=COMPLETED:bob@example.com\r\n",
+			"ORGANIZER:mailto:ann@example.com\n" +
+				"ATTENDEE;PARTSTAT=COMPLETED:mailto:bob@example.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:" + tt.comp + "\r\nUID:1\r\nDTSTART:20110608T060000Z\r\n" + tt.props +
+				"END:" + tt.comp + "\r\nEND:VCALENDAR\r\n"
+
+			entries := convertString(t, input, vcstoics.Options{Email: "me@example.com"})
+			if got := propertyLines(entries, "ORGANIZER", "ATTENDEE"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
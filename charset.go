@@ -0,0 +1,282 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	_ "embed"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Double-byte tables generated by charset_gen.go. Each entry is a big
+// endian uint16 code point, 0 meaning the pair is not mapped.
+var (
+	//go:embed tables/sjis.bin
+	sjisTable string
+	//go:embed tables/gbk.bin
+	gbkTable string
+)
+
+// charsetDecoder converts text in some character set to UTF-8
+type charsetDecoder func(b []byte) string
+
+var charsets = map[string]charsetDecoder{
+	"UTF8":        decodeUTF8,
+	"USASCII":     decodeUTF8,
+	"ASCII":       decodeUTF8,
+	"UTF16":       decodeUTF16,
+	"UTF16BE":     decodeUTF16BE,
+	"UTF16LE":     decodeUTF16LE,
+	"UCS2":        decodeUTF16,
+	"ISO88591":    decodeLatin1,
+	"LATIN1":      decodeLatin1,
+	"ISO885915":   decodeLatin9,
+	"LATIN9":      decodeLatin9,
+	"ISO88592":    decodeLatin2,
+	"LATIN2":      decodeLatin2,
+	"WINDOWS1252": decodeWindows1252,
+	"CP1252":      decodeWindows1252,
+	"KOI8R":       decodeKOI8R,
+	"SHIFTJIS":    decodeShiftJIS,
+	"SJIS":        decodeShiftJIS,
+	"CP932":       decodeShiftJIS,
+	"WINDOWS31J":  decodeShiftJIS,
+	"MSKANJI":     decodeShiftJIS,
+	"GB2312":      decodeGBK,
+	"EUCCN":       decodeGBK,
+	"GBK":         decodeGBK,
+	"CP936":       decodeGBK,
+}
+
+// normalizeCharset turns "iso_8859-1" or "ISO-8859-1" into "ISO88591"
+func normalizeCharset(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(name))
+}
+
+// IsKnownCharset reports whether the named character set can be decoded
+func IsKnownCharset(name string) bool {
+	_, ok := charsets[normalizeCharset(name)]
+	return ok
+}
+
+// decodeCharset converts b from the named character set to UTF-8. Bytes
+// that claim to be UTF-8 but are not valid are read as Windows-1252, the
+// most common mistake of old exporters. Unknown character sets are
+// treated as UTF-8.
+func decodeCharset(name string, b []byte) string {
+	decode, ok := charsets[normalizeCharset(name)]
+	if !ok {
+		decode = decodeUTF8
+	}
+	return decode(b)
+}
+
+func decodeUTF8(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return decodeWindows1252(b)
+}
+
+func decodeUTF16(b []byte) string {
+	switch {
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe:
+		return decodeUTF16LE(b[2:])
+	case len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff:
+		return decodeUTF16BE(b[2:])
+	default:
+		return decodeUTF16BE(b)
+	}
+}
+
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func decodeUTF16LE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func decodeLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// latin9 lists where ISO-8859-15 differs from ISO-8859-1
+var latin9 = map[byte]rune{
+	0xa4: '€', 0xa6: 'Š', 0xa8: 'š', 0xb4: 'Ž',
+	0xb8: 'ž', 0xbc: 'Œ', 0xbd: 'œ', 0xbe: 'Ÿ',
+}
+
+func decodeLatin9(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		if r, ok := latin9[c]; ok {
+			runes[i] = r
+		} else {
+			runes[i] = rune(c)
+		}
+	}
+	return string(runes)
+}
+
+// latin2 holds the 0xA0-0xFF range of ISO-8859-2
+var latin2 = [96]rune{
+	0x00A0, 0x0104, 0x02D8, 0x0141, 0x00A4, 0x013D, 0x015A, 0x00A7,
+	0x00A8, 0x0160, 0x015E, 0x0164, 0x0179, 0x00AD, 0x017D, 0x017B,
+	0x00B0, 0x0105, 0x02DB, 0x0142, 0x00B4, 0x013E, 0x015B, 0x02C7,
+	0x00B8, 0x0161, 0x015F, 0x0165, 0x017A, 0x02DD, 0x017E, 0x017C,
+	0x0154, 0x00C1, 0x00C2, 0x0102, 0x00C4, 0x0139, 0x0106, 0x00C7,
+	0x010C, 0x00C9, 0x0118, 0x00CB, 0x011A, 0x00CD, 0x00CE, 0x010E,
+	0x0110, 0x0143, 0x0147, 0x00D3, 0x00D4, 0x0150, 0x00D6, 0x00D7,
+	0x0158, 0x016E, 0x00DA, 0x0170, 0x00DC, 0x00DD, 0x0162, 0x00DF,
+	0x0155, 0x00E1, 0x00E2, 0x0103, 0x00E4, 0x013A, 0x0107, 0x00E7,
+	0x010D, 0x00E9, 0x0119, 0x00EB, 0x011B, 0x00ED, 0x00EE, 0x010F,
+	0x0111, 0x0144, 0x0148, 0x00F3, 0x00F4, 0x0151, 0x00F6, 0x00F7,
+	0x0159, 0x016F, 0x00FA, 0x0171, 0x00FC, 0x00FD, 0x0163, 0x02D9,
+}
+
+func decodeLatin2(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		if c >= 0xa0 {
+			runes[i] = latin2[c-0xa0]
+		} else {
+			runes[i] = rune(c)
+		}
+	}
+	return string(runes)
+}
+
+// windows1252 holds the 0x80-0x9F range, the rest matches ISO-8859-1
+var windows1252 = [32]rune{
+	0x20AC, 0xFFFD, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0xFFFD, 0x017D, 0xFFFD,
+	0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0xFFFD, 0x017E, 0x0178,
+}
+
+func decodeWindows1252(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		if c >= 0x80 && c < 0xa0 {
+			runes[i] = windows1252[c-0x80]
+		} else {
+			runes[i] = rune(c)
+		}
+	}
+	return string(runes)
+}
+
+// koi8r holds the upper half of KOI8-R
+var koi8r = [128]rune{
+	0x2500, 0x2502, 0x250C, 0x2510, 0x2514, 0x2518, 0x251C, 0x2524,
+	0x252C, 0x2534, 0x253C, 0x2580, 0x2584, 0x2588, 0x258C, 0x2590,
+	0x2591, 0x2592, 0x2593, 0x2320, 0x25A0, 0x2219, 0x221A, 0x2248,
+	0x2264, 0x2265, 0x00A0, 0x2321, 0x00B0, 0x00B2, 0x00B7, 0x00F7,
+	0x2550, 0x2551, 0x2552, 0x0451, 0x2553, 0x2554, 0x2555, 0x2556,
+	0x2557, 0x2558, 0x2559, 0x255A, 0x255B, 0x255C, 0x255D, 0x255E,
+	0x255F, 0x2560, 0x2561, 0x0401, 0x2562, 0x2563, 0x2564, 0x2565,
+	0x2566, 0x2567, 0x2568, 0x2569, 0x256A, 0x256B, 0x256C, 0x00A9,
+	0x044E, 0x0430, 0x0431, 0x0446, 0x0434, 0x0435, 0x0444, 0x0433,
+	0x0445, 0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E,
+	0x043F, 0x044F, 0x0440, 0x0441, 0x0442, 0x0443, 0x0436, 0x0432,
+	0x044C, 0x044B, 0x0437, 0x0448, 0x044D, 0x0449, 0x0447, 0x044A,
+	0x042E, 0x0410, 0x0411, 0x0426, 0x0414, 0x0415, 0x0424, 0x0413,
+	0x0425, 0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E,
+	0x041F, 0x042F, 0x0420, 0x0421, 0x0422, 0x0423, 0x0416, 0x0412,
+	0x042C, 0x042B, 0x0417, 0x0428, 0x042D, 0x0429, 0x0427, 0x042A,
+}
+
+func decodeKOI8R(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		if c >= 0x80 {
+			runes[i] = koi8r[c-0x80]
+		} else {
+			runes[i] = rune(c)
+		}
+	}
+	return string(runes)
+}
+
+// lookupDoubleByte finds a pair in one of the generated tables
+func lookupDoubleByte(table string, row int, trail, trailLo, trailHi byte) rune {
+	if trail < trailLo || trail > trailHi {
+		return utf8.RuneError
+	}
+	i := 2 * (row*(int(trailHi-trailLo)+1) + int(trail-trailLo))
+	r := rune(table[i])<<8 | rune(table[i+1])
+	if r == 0 {
+		return utf8.RuneError
+	}
+	return r
+}
+
+func decodeShiftJIS(b []byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			sb.WriteByte(c)
+		case c >= 0xa1 && c <= 0xdf:
+			// Half-width katakana
+			sb.WriteRune(0xff61 + rune(c-0xa1))
+		case (c >= 0x81 && c <= 0x9f || c >= 0xe0 && c <= 0xfc) && i+1 < len(b):
+			row := int(c - 0x81)
+			if c >= 0xe0 {
+				row = int(c-0xe0) + 0x9f - 0x81 + 1
+			}
+			r := lookupDoubleByte(sjisTable, row, b[i+1], 0x40, 0xfc)
+			if r != utf8.RuneError || b[i+1] >= 0x40 {
+				i++
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(utf8.RuneError)
+		}
+	}
+	return sb.String()
+}
+
+func decodeGBK(b []byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			sb.WriteByte(c)
+		case c == 0x80:
+			sb.WriteRune('€')
+		case c <= 0xfe && i+1 < len(b):
+			r := lookupDoubleByte(gbkTable, int(c-0x81), b[i+1], 0x40, 0xfe)
+			if r != utf8.RuneError || b[i+1] >= 0x40 {
+				i++
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(utf8.RuneError)
+		}
+	}
+	return sb.String()
+}
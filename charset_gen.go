@@ -0,0 +1,94 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+//go:build ignore
+
+// This program generates the double-byte charset tables embedded by
+// charset.go. It uses the system iconv, so the tables only need to be
+// regenerated when the list of supported charsets changes.
+//
+//	go run charset_gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+type table struct {
+	file    string
+	iconv   string
+	leads   [][2]byte // inclusive lead byte ranges
+	trailLo byte
+	trailHi byte
+}
+
+var tables = []table{
+	{
+		file:    "sjis.bin",
+		iconv:   "CP932",
+		leads:   [][2]byte{{0x81, 0x9f}, {0xe0, 0xfc}},
+		trailLo: 0x40,
+		trailHi: 0xfc,
+	},
+	{
+		file:    "gbk.bin",
+		iconv:   "GBK",
+		leads:   [][2]byte{{0x81, 0xfe}},
+		trailLo: 0x40,
+		trailHi: 0xfe,
+	},
+}
+
+func main() {
+	for _, t := range tables {
+		if err := generate(t); err != nil {
+			log.Fatalf("%s: %v", t.file, err)
+		}
+	}
+}
+
+func generate(t table) error {
+	// Every possible pair on its own line, iconv drops the invalid ones
+	var in bytes.Buffer
+	for _, r := range t.leads {
+		for lead := int(r[0]); lead <= int(r[1]); lead++ {
+			for trail := int(t.trailLo); trail <= int(t.trailHi); trail++ {
+				in.Write([]byte{byte(lead), byte(trail), '\n'})
+			}
+		}
+	}
+
+	cmd := exec.Command("iconv", "-c", "-f", t.iconv, "-t", "UTF-8")
+	cmd.Stdin = &in
+	out, err := cmd.Output()
+	if err != nil && len(out) == 0 {
+		return err
+	}
+
+	lines := bytes.Split(out, []byte{'\n'})
+	var table []byte
+	for _, line := range lines[:len(lines)-1] {
+		r, size := utf8.DecodeRune(line)
+		if size != len(line) || r < 0x80 || r > 0xffff {
+			r = 0
+		}
+		table = binary.BigEndian.AppendUint16(table, uint16(r))
+	}
+
+	want := 0
+	for _, r := range t.leads {
+		want += (int(r[1]) - int(r[0]) + 1) * (int(t.trailHi) - int(t.trailLo) + 1)
+	}
+	if len(table) != want*2 {
+		return fmt.Errorf("expected %d entries, got %d", want, len(table)/2)
+	}
+
+	return os.WriteFile(filepath.Join("tables", t.file), table, 0644)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func parseSummary(t *testing.T, line string, opts vcstoics.Options) string {
+	t.Helper()
+
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + line + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	cal, err := vcstoics.ParseVCalendarWithOptions(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cal.Components[0].Value("SUMMARY")
+}
+
+func TestCharsets(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"SUMMARY;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:caf=C3=A9", "café"},
+		{"SUMMARY;CHARSET=ISO-8859-1;ENCODING=QUOTED-PRINTABLE:caf=E9", "café"},
+		{"SUMMARY;CHARSET=ISO-8859-15;ENCODING=QUOTED-PRINTABLE:=A4", "€"},
+		{"SUMMARY;CHARSET=windows-1252;ENCODING=QUOTED-PRINTABLE:=80 =93x=94", "€ “x”"},
+		{"SUMMARY;CHARSET=KOI8-R;ENCODING=QUOTED-PRINTABLE:=F0=D2=C9=D7=C5=D4", "Привет"},
+		{"SUMMARY;CHARSET=Shift_JIS;ENCODING=QUOTED-PRINTABLE:=82=B1=82=F1=82=C9=82=BF=82=CD =B1", "こんにちは ｱ"},
+		{"SUMMARY;CHARSET=GB2312;ENCODING=QUOTED-PRINTABLE:=C4=E3=BA=C3", "你好"},
+		{"SUMMARY;CHARSET=GBK;ENCODING=QUOTED-PRINTABLE:=C4=E3=BA=C3", "你好"},
+		{"SUMMARY;CHARSET=UTF-16LE;ENCODING=QUOTED-PRINTABLE:A=00=3D=D8=00=DE", "A😀"},
+		{"SUMMARY;CHARSET=UTF-16;ENCODING=QUOTED-PRINTABLE:=FF=FEA=00", "A"},
+		// Not valid UTF-8, read as Windows-1252
+		{"SUMMARY;ENCODING=QUOTED-PRINTABLE:Dreik=F6nigstag", "Dreikönigstag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := parseSummary(t, tt.line, vcstoics.Options{}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultCharset(t *testing.T) {
+	opts := vcstoics.Options{DefaultCharset: "KOI8-R"}
+
+	if got := parseSummary(t, "SUMMARY;ENCODING=QUOTED-PRINTABLE:=F0=D2=C9=D7=C5=D4", opts); got != "Привет" {
+		t.Errorf("got %q, want %q", got, "Привет")
+	}
+
+	// An explicit CHARSET parameter wins over the default
+	if got := parseSummary(t, "SUMMARY;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:=C3=A9", opts); got != "é" {
+		t.Errorf("got %q, want %q", got, "é")
+	}
+}
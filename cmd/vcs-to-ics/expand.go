@@ -0,0 +1,135 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+// parseTime accepts dates, RFC 3339 and iCalendar date-times
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected e.g. 2011-06-01 or 2011-06-01T10:00:00Z", s)
+}
+
+// formatOccurrence formats the start or end of an occurrence for listing
+func formatOccurrence(occ vcstoics.Occurrence, t time.Time) string {
+	switch {
+	case occ.AllDay:
+		return t.Format("2006-01-02")
+	case occ.Floating:
+		return t.Format("2006-01-02T15:04:05")
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+// runExpand implements the expand command, which lists the occurrences of
+// the entries within a period or writes them as separate events
+func runExpand(args []string) error {
+	fs := flag.NewFlagSet("expand", flag.ExitOnError)
+
+	var (
+		fromStr      string
+		toStr        string
+		email        string
+		writeICS     bool
+		clamp        bool
+		charset      string
+		inputCharset string
+		timeZone     string
+		allDay       string
+	)
+
+	fs.StringVar(&fromStr, "from", "", "start of the period, e.g. 2011-06-01")
+	fs.StringVar(&toStr, "to", "", "end of the period, exclusive")
+	fs.StringVar(&email, "email", "", "email address of the organizer of entries that do not name one, with -ics")
+	fs.BoolVar(&writeICS, "ics", false, "write the occurrences as separate events of an ICS file instead of listing them")
+	fs.BoolVar(&clamp, "clamp", false, "move occurrences on days a month does not have to its last day")
+	fs.StringVar(&charset, "charset", "", "character set of values without a CHARSET parameter")
+	fs.StringVar(&inputCharset, "input-charset", "auto", "character set of the input files, detected when auto")
+	fs.StringVar(&allDay, "all-day", "utc", "which entries with UTC times and no time zone are all-day: utc for UTC midnight spans, strict for none, guess for whole days in any zone")
+	fs.StringVar(&timeZone, "tz", "", "time zone of local times: auto to guess the IANA zone from the file, or an IANA zone name")
+
+	fs.Parse(args)
+
+	if fromStr == "" || toStr == "" {
+		return fmt.Errorf("both -from and -to are required")
+	}
+	from, err := parseTime(fromStr)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime(toStr)
+	if err != nil {
+		return err
+	}
+
+	if charset != "" && !vcstoics.IsKnownCharset(charset) {
+		return fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	if inputCharset != "auto" && !vcstoics.IsKnownCharset(inputCharset) {
+		return fmt.Errorf("unsupported input charset: %s", inputCharset)
+	}
+
+	allDayPolicy, err := vcstoics.ParseAllDayPolicy(allDay)
+	if err != nil {
+		return err
+	}
+
+	opts := vcstoics.Options{
+		Email:          email,
+		DefaultCharset: charset,
+		InputEncoding:  inputCharset,
+		TimeZone:       timeZone,
+		ClampMonthEnd:  clamp,
+		AllDay:         allDayPolicy,
+		Warnf:          warning,
+	}
+
+	in, closeInputs, err := inputs(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer closeInputs()
+
+	var instances []vcstoics.Instance
+	for _, r := range in {
+		found, err := vcstoics.Expand(r, from, to, opts)
+		if err != nil {
+			return err
+		}
+		instances = append(instances, found...)
+	}
+	slices.SortStableFunc(instances, func(a, b vcstoics.Instance) int { return a.Start.Compare(b.Start) })
+
+	if !writeICS {
+		for _, inst := range instances {
+			fmt.Printf("%s\t%s\t%s\n", formatOccurrence(inst.Occurrence, inst.Start),
+				formatOccurrence(inst.Occurrence, inst.End), strings.ReplaceAll(inst.Entry.Summary, "\n", " "))
+		}
+		return nil
+	}
+
+	writer := vcstoics.NewICSWriter(email, os.Stdout)
+	writer.Warnf = warning
+	for _, inst := range instances {
+		if err := writer.AddEntry(inst.Flatten()); err != nil {
+			return fmt.Errorf("error adding event: %w", err)
+		}
+	}
+	return writer.Close()
+}
@@ -18,16 +18,40 @@ func warning(format string, v ...any) {
 	fmt.Fprintf(os.Stderr, format, v...)
 }
 
+func info(format string, v ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
+
 func run() error {
 	var (
-		email  string
-		merge  bool
-		output string
+		email        string
+		merge        bool
+		output       string
+		charset      string
+		inputCharset string
+		uidDomain    string
+		timeZone     string
+		verbose      bool
+		lang         string
+		priority     int
+		attachments  string
+		unknown      string
+		allDay       string
 	)
 
-	flag.StringVar(&email, "email", "", "recipient email address for the calendar event")
+	flag.StringVar(&email, "email", "", "email address of the organizer of entries that do not name one")
 	flag.BoolVar(&merge, "merge", false, "create a single ICS file for all events")
 	flag.StringVar(&output, "o", "", "output directory for the .ics files")
+	flag.StringVar(&charset, "charset", "", "character set of values without a CHARSET parameter")
+	flag.StringVar(&inputCharset, "input-charset", "auto", "character set of the input files, detected when auto")
+	flag.StringVar(&timeZone, "tz", "", "time zone of local times: auto to guess the IANA zone from the file, or an IANA zone name")
+	flag.StringVar(&uidDomain, "uid-domain", vcstoics.DefaultUIDDomain, "domain of the UIDs generated for entries without one")
+	flag.BoolVar(&verbose, "v", false, "describe the recurrence of every recurring entry on standard error")
+	flag.StringVar(&lang, "lang", "en", "language of the recurrence descriptions: en or de")
+	flag.StringVar(&attachments, "attachments", "", "directory to extract inline attachments to, referring to them by file URI")
+	flag.StringVar(&allDay, "all-day", "utc", "which entries with UTC times and no time zone are all-day: utc for UTC midnight spans, strict for none, guess for whole days in any zone")
+	flag.StringVar(&unknown, "unknown", "drop", "what to do with properties that are not converted: drop, keep or prefix them with "+vcstoics.UnknownPrefix)
+	flag.IntVar(&priority, "priority-scale", vcstoics.DefaultPriorityScale, "highest priority used in the input files, 9 keeps priorities as they are")
 
 	flag.Parse()
 
@@ -35,46 +59,111 @@ func run() error {
 		return fmt.Errorf("missing email address")
 	}
 
-	var in []io.Reader
+	if charset != "" && !vcstoics.IsKnownCharset(charset) {
+		return fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	if inputCharset != "auto" && !vcstoics.IsKnownCharset(inputCharset) {
+		return fmt.Errorf("unsupported input charset: %s", inputCharset)
+	}
+
+	unknownPolicy, err := vcstoics.ParseUnknownPolicy(unknown)
+	if err != nil {
+		return err
+	}
+
+	allDayPolicy, err := vcstoics.ParseAllDayPolicy(allDay)
+	if err != nil {
+		return err
+	}
+
+	locale, ok := vcstoics.Locales[lang]
+	if !ok {
+		return fmt.Errorf("unsupported language: %s", lang)
+	}
 
-	if files := flag.Args(); len(files) > 0 {
-		for _, name := range files {
-			if !strings.HasSuffix(name, ".vcs") {
-				warning("%s may not be a vcs file: is missing .vcs file extension", name)
-			}
+	opts := vcstoics.Options{
+		Email:             email,
+		DefaultCharset:    charset,
+		InputEncoding:     inputCharset,
+		UIDDomain:         uidDomain,
+		TimeZone:          timeZone,
+		PriorityScale:     priority,
+		AttachmentDir:     attachments,
+		UnknownProperties: unknownPolicy,
+		AllDay:            allDayPolicy,
+		Locale:            locale,
+		Warnf:             warning,
+	}
+	if verbose {
+		opts.Logf = info
+	}
 
-			f, err := os.OpenFile(name, os.O_RDONLY, 0444)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
+	in, closeInputs, err := inputs(flag.Args())
+	if err != nil {
+		return err
+	}
+	defer closeInputs()
 
-			in = append(in, f)
+	for _, r := range in {
+		err := vcstoics.ConvertWithOptions(r, os.Stdout, opts)
+		if err != nil {
+			return err
 		}
-	} else {
+	}
+
+	return nil
+}
+
+// inputs opens the named .vcs files, or standard input when there are
+// none and it is not a terminal
+func inputs(files []string) ([]io.Reader, func(), error) {
+	var (
+		in     []io.Reader
+		opened []*os.File
+	)
+	closeAll := func() {
+		for _, f := range opened {
+			f.Close()
+		}
+	}
+
+	if len(files) == 0 {
 		stat, err := os.Stdin.Stat()
 		if err != nil {
-			return fmt.Errorf("failed to get stdin status: %w", err)
+			return nil, nil, fmt.Errorf("failed to get stdin status: %w", err)
 		}
 
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			in = append(in, os.Stdin)
-		} else {
-			return fmt.Errorf("no .vcs files were specified")
+		if (stat.Mode() & os.ModeCharDevice) != 0 {
+			return nil, nil, fmt.Errorf("no .vcs files were specified")
 		}
+		return []io.Reader{os.Stdin}, closeAll, nil
 	}
 
-	for _, r := range in {
-		err := vcstoics.Convert(r, os.Stdout, email)
+	for _, name := range files {
+		if !strings.HasSuffix(name, ".vcs") {
+			warning("%s may not be a vcs file: is missing .vcs file extension", name)
+		}
+
+		f, err := os.OpenFile(name, os.O_RDONLY, 0444)
 		if err != nil {
-			return err
+			closeAll()
+			return nil, nil, err
 		}
+
+		opened = append(opened, f)
+		in = append(in, f)
 	}
 
-	return nil
+	return in, closeAll, nil
 }
 
 func main() {
+	run := run
+	if len(os.Args) > 1 && os.Args[1] == "expand" {
+		run = func() error { return runExpand(os.Args[2:]) }
+	}
+
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,188 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// bareParams maps the values vCalendar 1.0 allows without a parameter
+// name to the parameter they belong to. Any other bare value is a TYPE.
+var bareParams = map[string]string{
+	"7BIT":             "ENCODING",
+	"8BIT":             "ENCODING",
+	"QUOTED-PRINTABLE": "ENCODING",
+	"BASE64":           "ENCODING",
+	"B":                "ENCODING",
+	"INLINE":           "VALUE",
+	"URL":              "VALUE",
+	"CONTENT-ID":       "VALUE",
+	"CID":              "VALUE",
+}
+
+// parseContentLine splits a content line into name, parameters and value.
+//
+//	contentline = name *(";" param) ":" value
+//	param       = param-name "=" param-value *("," param-value) / param-value
+//	param-value = paramtext / quoted-string
+func parseContentLine(line string) (*Property, error) {
+	i := strings.IndexAny(line, ";:")
+	if i < 0 {
+		return nil, fmt.Errorf("missing ':' in content line %q", line)
+	}
+
+	prop := &Property{Name: strings.ToUpper(strings.TrimSpace(line[:i]))}
+	if prop.Name == "" {
+		return nil, fmt.Errorf("missing property name in content line %q", line)
+	}
+
+	for line[i] == ';' {
+		param, n, err := parseParam(line[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", prop.Name, err)
+		}
+		if param.Name != "" {
+			prop.Params = append(prop.Params, param)
+		}
+
+		i += 1 + n
+		if i >= len(line) {
+			return nil, fmt.Errorf("missing ':' in content line %q", line)
+		}
+	}
+
+	prop.Raw = line[i+1:]
+	return prop, nil
+}
+
+// parseParam parses a single parameter at the start of s and returns it
+// together with the number of bytes consumed. Parsing stops at the ';' or
+// ':' that ends the parameter.
+func parseParam(s string) (Param, int, error) {
+	var (
+		param  Param
+		values []string
+		value  strings.Builder
+		quoted bool
+		named  bool
+		i      int
+	)
+
+loop:
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted:
+			if c == '"' {
+				quoted = false
+			} else {
+				value.WriteByte(c)
+			}
+		case c == '"':
+			quoted = true
+		case c == '=' && !named:
+			param.Name = strings.ToUpper(strings.TrimSpace(value.String()))
+			value.Reset()
+			named = true
+		case c == ',' && named:
+			values = append(values, value.String())
+			value.Reset()
+		case c == ';' || c == ':':
+			break loop
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return Param{}, 0, fmt.Errorf("unterminated quoted parameter value")
+	}
+
+	last := strings.TrimSpace(value.String())
+	if !named {
+		// vCalendar 1.0 allows parameters like ";QUOTED-PRINTABLE"
+		if last == "" {
+			return Param{}, i, nil
+		}
+		last = strings.ToUpper(last)
+		param.Name = bareParams[last]
+		if param.Name == "" {
+			param.Name = "TYPE"
+		}
+		param.Bare = true
+	}
+
+	param.Values = append(values, last)
+	return param, i, nil
+}
+
+// decodeValue applies the ENCODING and CHARSET parameters to a raw
+// property value. defaultCharset is used for text values without CHARSET.
+func decodeValue(params Params, raw, defaultCharset string) string {
+	charset := params.Get("CHARSET")
+	if charset == "" {
+		charset = defaultCharset
+	}
+
+	switch strings.ToUpper(params.Get("ENCODING")) {
+	case "QUOTED-PRINTABLE":
+		return decodeCharset(charset, []byte(Decode(raw)))
+	case "BASE64", "B":
+		data, err := decodeBase64(raw)
+		if err != nil {
+			return raw
+		}
+		// Binary content unless a charset says it is text
+		if params.Has("CHARSET") {
+			return decodeCharset(charset, data)
+		}
+		return string(data)
+	default:
+		// 7BIT, 8BIT or no encoding at all
+		return decodeCharset(charset, []byte(raw))
+	}
+}
+
+// decodeBase64 decodes BASE64 data. Folding may leave whitespace in the
+// middle of it.
+func decodeBase64(raw string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(raw), ""))
+}
+
+func isBase64(params Params) bool {
+	switch strings.ToUpper(params.Get("ENCODING")) {
+	case "BASE64", "B":
+		return true
+	}
+	return false
+}
+
+// isBase64Line reports whether a line holds nothing but BASE64 data
+func isBase64Line(line string) bool {
+	for _, c := range []byte(line) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '+' || c == '/' || c == '=' || c == ' ' || c == '\t':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isQuotedPrintable(params Params) bool {
+	return strings.EqualFold(params.Get("ENCODING"), "QUOTED-PRINTABLE")
+}
+
+// isEncoded reports whether the value uses a transfer encoding, as opposed
+// to being plain text in the document's own encoding
+func isEncoded(params Params) bool {
+	switch strings.ToUpper(params.Get("ENCODING")) {
+	case "QUOTED-PRINTABLE", "BASE64", "B":
+		return true
+	}
+	return false
+}
@@ -4,21 +4,19 @@
 package vcstoics
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
-	"unicode"
+	"time"
 )
 
-// Decode decodes quoted-printable text
+// Decode decodes quoted-printable text. Encoded line breaks are kept as
+// they are, see UnescapeText for turning the result into plain text.
 func Decode(input string) string {
-	// Replace CRLF codes
-	input = strings.ReplaceAll(input, "=0D=0A", "\\n")
-
 	// Decode the quoted-printable content
 	result, err := decodeQuotedPrintable(input)
 	if err != nil {
@@ -62,200 +60,252 @@ func isHexChar(c byte) bool {
 	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'F') || (c >= 'a' && c <= 'f')
 }
 
-// Read a field that may continue on the next line
-func readPossibleMultiline(fieldContent string, reader *bufio.Reader) (string, error) {
-	var sb strings.Builder
-	sb.WriteString(fieldContent)
-
-	for {
-		// Mark the current position for possible reset
-		reader.Reset(reader)
+// Options controls how a vCalendar document is converted
+type Options struct {
+	// Email is written as PRODID, and as ORGANIZER of entries that do not
+	// name one
+	Email string
+
+	// Now returns the time used for DTSTAMP when an entry has neither
+	// LAST-MODIFIED nor DTSTAMP. Defaults to time.Now.
+	Now func() time.Time
+
+	// DefaultCharset is the character set of values without a CHARSET
+	// parameter. Defaults to the charset of 8-bit input documents, or
+	// UTF-8 falling back to Windows-1252 for values that are not valid
+	// UTF-8.
+	DefaultCharset string
+
+	// InputEncoding forces the encoding of the whole input document, e.g.
+	// UTF-16LE or ISO-8859-1. When empty or "auto" it is detected from the
+	// byte order mark, the layout of the first bytes or, for 8-bit input
+	// that is not UTF-8, guessed from byte statistics.
+	InputEncoding string
+
+	// UIDDomain is the domain part of UIDs generated for entries that do
+	// not have one. Defaults to DefaultUIDDomain.
+	UIDDomain string
+
+	// TimeZone selects the time zone of local date-times. When empty the
+	// TZ and DAYLIGHT headers are turned into a VTIMEZONE as they are,
+	// "auto" looks for the IANA zone matching them and any other value
+	// forces that IANA zone, e.g. Europe/Berlin.
+	TimeZone string
+
+	// ClampMonthEnd moves occurrences on days a month does not have to
+	// its last day when expanding recurrences, see Expansion
+	ClampMonthEnd bool
+
+	// PriorityScale is the highest PRIORITY used by the vCalendar producer.
+	// Priorities are spread over the RFC 5545 range of 1 to 9. Defaults to
+	// DefaultPriorityScale.
+	PriorityScale int
+
+	// AttachmentDir, when set, is where inline attachments are written to,
+	// creating it if needed. The entries then refer to the files by URI
+	// instead of embedding them.
+	AttachmentDir string
+
+	// UnknownProperties tells what happens to properties of entries that
+	// are not converted. They are dropped by default.
+	UnknownProperties UnknownPolicy
+
+	// AllDay tells which entries with UTC times are all-day entries when
+	// the time zone is not known, see AllDayPolicy
+	AllDay AllDayPolicy
+
+	// Logf, when set, reports every recurring entry converted with a
+	// description of its recurrence rule.
+	Logf func(format string, v ...any)
+
+	// Locale selects the language of the recurrence descriptions passed to
+	// Logf; English by default.
+	Locale Locale
+
+	// Warnf reports problems that do not stop the conversion, such as
+	// unknown properties or a guessed input encoding. Defaults to
+	// printing to standard error.
+	Warnf func(format string, v ...any)
+}
 
-		// Peek at the next character
-		c, err := reader.ReadByte()
-		if err != nil {
-			if err == io.EOF {
-				// End of file, return what we have
-				return sb.String(), nil
-			}
-			return "", err
-		}
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
 
-		// If it's a space, this is a continuation line
-		if unicode.IsSpace(rune(c)) {
-			// Read the rest of the line
-			line, err := reader.ReadString('\n')
-			if err != nil && err != io.EOF {
-				return "", err
-			}
-			sb.WriteString(strings.TrimRight(line, "\r\n"))
-		} else {
-			// Not a continuation, unread the byte and return
-			reader.UnreadByte()
-			break
-		}
+func (o Options) warnf(format string, v ...any) {
+	if o.Warnf != nil {
+		o.Warnf(format, v...)
+		return
 	}
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
 
-	return sb.String(), nil
+// Convert reads a vCalendar 1.0 document from in and writes it as an
+// iCalendar (RFC 5545) document to out
+func Convert(in io.Reader, out io.Writer, email string) error {
+	return ConvertWithOptions(in, out, Options{Email: email})
 }
 
-// Read a quoted-printable field that may span multiple lines
-func readEncryptedField(fieldContent string, reader *bufio.Reader) (string, error) {
-	var sb strings.Builder
-	sb.WriteString(fieldContent)
+// ConvertWithOptions is like Convert but allows tuning the conversion
+func ConvertWithOptions(in io.Reader, out io.Writer, opts Options) error {
+	cal, err := ParseVCalendarWithOptions(in, opts)
+	if errors.Is(err, ErrNoCalendar) {
+		// Keep producing an (empty) calendar for unrecognised input
+		opts.warnf("No calendar found in input")
+		cal = &Calendar{}
+	} else if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
 
-	// If the line ends with =, it continues on the next line
-	for strings.HasSuffix(sb.String(), "=") {
-		// Remove the trailing =
-		str := sb.String()
-		sb.Reset()
-		sb.WriteString(str[:len(str)-1])
+	writer := NewICSWriter(opts.Email, out)
+	if opts.Now != nil {
+		writer.Now = opts.Now
+	}
+	writer.Warnf = opts.warnf
+	writer.PriorityScale = opts.PriorityScale
+	writer.AttachmentDir = opts.AttachmentDir
+	writer.Unknown = opts.UnknownProperties
+	writer.AllDay = opts.AllDay
+	defer writer.Close()
 
-		// Read the next line
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				return "", fmt.Errorf("unexpected EOF while reading multiline field")
-			}
-			return "", err
+	tz, err := resolveTimeZone(cal, opts)
+	if err != nil {
+		return err
+	}
+	if tz != nil {
+		if err := writer.AddTimeZone(tz); err != nil {
+			return fmt.Errorf("error adding time zone: %w", err)
 		}
-		sb.WriteString(strings.TrimRight(line, "\r\n"))
 	}
 
-	// Decode the content
-	return Decode(sb.String()), nil
-}
-
-func Convert(in io.Reader, out io.Writer, email string) error {
-	writer := NewICSWriter(email, out)
-	defer writer.Close()
+	for _, prop := range cal.Properties {
+		switch prop.Name {
+		case "PRODID", "VERSION", "TZ", "DAYLIGHT":
+			// Known headers, skip silently
+		default:
+			opts.warnf("Unknown header entry: %s:%s", prop.Name, prop.Raw)
+		}
+	}
 
-	reader := bufio.NewReader(in)
+	for _, comp := range cal.Components {
+		if comp.Name != "VEVENT" && comp.Name != "VTODO" {
+			opts.warnf("Unknown component: %s", comp.Name)
+			continue
+		}
 
-	var (
-		line string
-		err  error
-	)
+		entry := NewEntry(comp, opts)
+		if tz != nil {
+			entry.TZID = tz.ID
+		}
 
-	for {
-		line, err = reader.ReadString('\n')
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("error reading file: %w", err)
+		if err := writer.AddEntry(entry); err != nil {
+			return fmt.Errorf("error adding event: %w", err)
 		}
 
-		// Trim trailing newlines
-		line = strings.TrimRight(line, "\r\n")
-
-		// Process the current line
-		if strings.EqualFold(line, "END:VCALENDAR") {
-			break
-		} else if !strings.EqualFold(line, "BEGIN:VTODO") && !strings.EqualFold(line, "BEGIN:VEVENT") {
-			// Skip headers and other non-event data
-			if strings.EqualFold(line, "BEGIN:VCALENDAR") ||
-				strings.HasPrefix(strings.ToUpper(line), "PRODID:") ||
-				strings.HasPrefix(strings.ToUpper(line), "VERSION:") {
-				// Known headers, skip silently
-			} else if line != "" {
-				fmt.Fprintf(os.Stderr, "Unknown header entry: %s\n", line)
-			}
-		} else {
-			// Processing an event or todo
-			isEvent := strings.EqualFold(line, "BEGIN:VEVENT")
+		if opts.Logf != nil {
+			logRecurrence(entry, tz, opts)
+		}
+	}
 
-			var summary, location, description, status, due, sequence string
-			var dtstart, dtend, dtstamp, rrule, alarm string
+	return nil
+}
 
-			// Process the event or todo
-			for {
-				line, err = reader.ReadString('\n')
-				if err != nil && err != io.EOF {
-					return fmt.Errorf("error reading file: %w", err)
-				}
+// resolveTimeZone picks the time zone of the calendar according to
+// Options.TimeZone
+func resolveTimeZone(cal *Calendar, opts Options) (*TimeZone, error) {
+	tz, err := cal.TimeZone()
+	if err != nil {
+		opts.warnf("Ignoring time zone: %v", err)
+		tz = nil
+	}
 
-				// Trim trailing newlines
-				line = strings.TrimRight(line, "\r\n")
+	from, to := calendarSpan(cal, tz, opts.now())
 
-				// Check for end of event/todo
-				if strings.EqualFold(line, "END:VEVENT") || strings.EqualFold(line, "END:VTODO") {
-					break
-				}
+	switch opts.TimeZone {
+	case "":
+		return tz, nil
+	case "auto":
+		if tz == nil {
+			return nil, nil
+		}
 
-				// Process fields
-				if strings.HasPrefix(strings.ToUpper(line), "SUMMARY:") {
-					summary, err = readPossibleMultiline(line[8:], reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "SUMMARY;ENCODING=QUOTED-PRINTABLE") {
-					encodedPart := line[strings.Index(line, ":")+1:]
-					summary, err = readEncryptedField(encodedPart, reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "LOCATION:") {
-					location, err = readPossibleMultiline(line[9:], reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "LOCATION;ENCODING=QUOTED-PRINTABLE") {
-					encodedPart := line[strings.Index(line, ":")+1:]
-					location, err = readEncryptedField(encodedPart, reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "DESCRIPTION:") {
-					description, err = readPossibleMultiline(line[12:], reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "DESCRIPTION;ENCODING=QUOTED-PRINTABLE") {
-					encodedPart := line[strings.Index(line, ":")+1:]
-					description, err = readEncryptedField(encodedPart, reader)
-					if err != nil {
-						return err
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "DTSTART:") {
-					dtstart = line[8:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "DTEND:") {
-					dtend = line[6:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "DUE:") {
-					due = line[4:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "STATUS:") {
-					status = line[7:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "SEQUENCE:") {
-					sequence = line[9:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "RRULE:") {
-					rrule = line[6:]
-				} else if strings.HasPrefix(strings.ToUpper(line), "AALARM:") ||
-					strings.HasPrefix(strings.ToUpper(line), "AALARM;TYPE=X-EPOCSOUND:") {
-					colonIdx := strings.Index(line, ":")
-					if colonIdx != -1 {
-						semicolonIdx := strings.Index(line[colonIdx+1:], ";")
-						if semicolonIdx != -1 {
-							alarm = line[colonIdx+1 : colonIdx+1+semicolonIdx]
-						}
-					}
-				} else if strings.HasPrefix(strings.ToUpper(line), "LAST-MODIFIED:") {
-					dtstamp = line[14:]
-				}
-				// Skip other fields for now
+		zones := ResolveIANAZones(tz, from)
+		if len(zones) == 0 {
+			opts.warnf("No IANA time zone matches %s, keeping it as is", tz.ID)
+			return tz, nil
+		}
+		if len(zones) > 1 {
+			opts.warnf("Time zone %s is ambiguous, using %s out of %d matching zones (e.g. %s)",
+				tz.ID, zones[0], len(zones), strings.Join(zones[1:min(len(zones), 4)], ", "))
+		}
 
-				if err == io.EOF {
-					break
-				}
-			}
+		loc, err := time.LoadLocation(zones[0])
+		if err != nil {
+			return nil, err
+		}
+		return TimeZoneFromLocation(loc, from, to), nil
+	default:
+		loc, err := time.LoadLocation(opts.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone: %w", err)
+		}
+		return TimeZoneFromLocation(loc, from, to), nil
+	}
+}
 
-			// Add the event to the ICS writer
-			err = writer.AddEvent(isEvent, summary, description, location, dtstart, dtend, rrule, dtstamp, sequence, due, status, alarm)
-			if err != nil {
-				return fmt.Errorf("error adding event: %w", err)
+// calendarSpan returns the earliest and latest dates found in the
+// entries and daylight saving periods of the calendar, or now if there
+// are none
+func calendarSpan(cal *Calendar, tz *TimeZone, now time.Time) (from, to time.Time) {
+	var times []time.Time
+	for _, comp := range cal.Components {
+		for _, name := range []string{"DTSTART", "DTEND", "DUE"} {
+			if t, err := ParseDate(comp.Value(name)); err == nil {
+				times = append(times, t)
 			}
 		}
+	}
+	if tz != nil {
+		for _, rule := range tz.Daylight {
+			times = append(times, rule.Start, rule.End)
+		}
+	}
+
+	if len(times) == 0 {
+		return now, now
+	}
 
-		if err == io.EOF {
-			break
+	from, to = times[0], times[0]
+	for _, t := range times[1:] {
+		if t.Before(from) {
+			from = t
+		}
+		if t.After(to) {
+			to = t
 		}
 	}
+	return from, to
+}
 
-	return nil
+// logRecurrence describes the recurrence rule of a converted entry
+func logRecurrence(e *Entry, tz *TimeZone, opts Options) {
+	// Ignored times are reported when the entry is written
+	rule := e.repeatRule(e.allDayStart(tz, opts.AllDay), func(string, ...any) {})
+	if rule == nil {
+		return
+	}
+
+	locale := opts.Locale
+	if locale == nil {
+		locale = English
+	}
+
+	name := e.Summary
+	if name == "" {
+		name = e.UID
+	}
+	opts.Logf("%s: %s", strings.ReplaceAll(name, "\n", " "), rule.DescribeIn(locale))
 }
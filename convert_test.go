@@ -5,17 +5,27 @@ package vcstoics_test
 
 import (
 	"bytes"
+	"flag"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	vcstoics "github.com/dvcorreia/vcs-to-ics"
 )
 
+var update = flag.Bool("update", false, "update the golden .ics files")
+
 func TestConvert(t *testing.T) {
 	const email = "dv_correia@hotmail.com"
 
+	// Fixed clock so entries without LAST-MODIFIED get a stable DTSTAMP
+	now := func() time.Time {
+		return time.Date(2025, 5, 20, 14, 1, 40, 0, time.UTC)
+	}
+
 	goldenFilesDir := "testdata"
 	vcsDir := filepath.Join(goldenFilesDir, "vcs")
 	icsDir := filepath.Join(goldenFilesDir, "ics")
@@ -49,18 +59,28 @@ func TestConvert(t *testing.T) {
 			}
 			defer vcsFile.Close()
 
-			expectedICS, err := os.ReadFile(icsPath)
-			if err != nil {
-				t.Fatalf("failed to read expected ICS file %s: %v", icsPath, err)
-			}
-
 			var output bytes.Buffer
-			err = vcstoics.Convert(vcsFile, &output, email)
+			err = vcstoics.ConvertWithOptions(vcsFile, &output, vcstoics.Options{
+				Email: email,
+				Now:   now,
+				Warnf: t.Logf,
+			})
 			if err != nil {
 				t.Fatalf("convert function failed for %s: %v", vcsFileName, err)
 			}
 
 			actualICS := output.Bytes()
+			if *update {
+				if err := os.WriteFile(icsPath, actualICS, 0644); err != nil {
+					t.Fatalf("failed to update ICS file %s: %v", icsPath, err)
+				}
+			}
+
+			expectedICS, err := os.ReadFile(icsPath)
+			if err != nil {
+				t.Fatalf("failed to read expected ICS file %s: %v", icsPath, err)
+			}
+
 			if !bytes.Equal(actualICS, expectedICS) {
 				t.Errorf("output mismatch for %s\nexpected:\n%s\n\nactual:\n%s",
 					vcsFileName, string(expectedICS), string(actualICS))
@@ -68,3 +88,71 @@ func TestConvert(t *testing.T) {
 		})
 	}
 }
+
+// convertString converts a vCalendar document with the clock of
+// TestConvert and returns the entries of the output: its VEVENT and VTODO
+// components, unfolded and with LF line endings. The test fails on errors
+// and, unless opts.Warnf is set, on warnings.
+func convertString(t *testing.T, input string, opts vcstoics.Options) string {
+	t.Helper()
+
+	if opts.Now == nil {
+		opts.Now = func() time.Time { return time.Date(2025, 5, 20, 14, 1, 40, 0, time.UTC) }
+	}
+	if opts.Warnf == nil {
+		opts.Warnf = func(format string, v ...any) { t.Errorf("unexpected warning: "+format, v...) }
+	}
+
+	var out bytes.Buffer
+	if err := vcstoics.ConvertWithOptions(strings.NewReader(input), &out, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries strings.Builder
+	inEntry := false
+	for _, line := range strings.Split(strings.ReplaceAll(out.String(), "\r\n ", ""), "\r\n") {
+		if line == "BEGIN:VEVENT" || line == "BEGIN:VTODO" {
+			inEntry = true
+		}
+		if inEntry {
+			entries.WriteString(line + "\n")
+		}
+		if line == "END:VEVENT" || line == "END:VTODO" {
+			inEntry = false
+		}
+	}
+	return entries.String()
+}
+
+// propertyLines returns the lines of entries, as returned by
+// convertString, of the named properties
+func propertyLines(entries string, names ...string) string {
+	var lines strings.Builder
+	for _, line := range strings.SplitAfter(entries, "\n") {
+		name, _, _ := strings.Cut(line, ":")
+		name, _, _ = strings.Cut(name, ";")
+		if slices.Contains(names, name) {
+			lines.WriteString(line)
+		}
+	}
+	return lines.String()
+}
+
+// components returns the named components within entries, as returned by
+// convertString
+func components(entries, name string) string {
+	var lines strings.Builder
+	inComponent := false
+	for _, line := range strings.SplitAfter(entries, "\n") {
+		if line == "BEGIN:"+name+"\n" {
+			inComponent = true
+		}
+		if inComponent {
+			lines.WriteString(line)
+		}
+		if line == "END:"+name+"\n" {
+			inComponent = false
+		}
+	}
+	return lines.String()
+}
@@ -18,6 +18,35 @@ func FormatDate(t time.Time) string {
 	return t.UTC().Format("20060102T150405Z")
 }
 
+// FormatLocalDate formats a time into ICS local time format, without
+// converting it to UTC
+func FormatLocalDate(t time.Time) string {
+	return t.Format("20060102T150405")
+}
+
+// TimeKind is the value type a date or date-time is written as
+type TimeKind int
+
+// TimeKind constants
+const (
+	UTCTime   TimeKind = iota // date-time in UTC, ending in Z
+	LocalTime                 // floating or zoned date-time, without Z
+	DateOnly                  // date without a time of day
+)
+
+// FormatTime formats a time as the given value type. Only UTCTime converts
+// t to UTC, the others use its fields as they are.
+func FormatTime(t time.Time, kind TimeKind) string {
+	switch kind {
+	case LocalTime:
+		return FormatLocalDate(t)
+	case DateOnly:
+		return FormatTimeForDayEvent(t)
+	default:
+		return FormatDate(t)
+	}
+}
+
 // ParseDate parses a date string in ICS format
 func ParseDate(date string) (time.Time, error) {
 	if strings.HasSuffix(date, "Z") {
@@ -36,3 +65,16 @@ func ParseDate(date string) (time.Time, error) {
 	}
 	return t, nil
 }
+
+// utcValue converts a date-time to UTC, using tz for local times. Floating
+// times are taken as UTC when the time zone is not known.
+func utcValue(value string, tz *TimeZone) (string, error) {
+	t, err := ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(value, "Z") && tz != nil {
+		t = tz.ToUTC(t)
+	}
+	return FormatDate(t), nil
+}
@@ -0,0 +1,314 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale words the parts of a recurrence rule for RepeatRule.Describe.
+// Negative numbers count from the end, as in the By fields of RepeatRule.
+type Locale interface {
+	Every(f Frequency, interval int) string // "every 2 weeks"
+	OnWeekdays(days []WeekdayNum) string    // "on Monday and the last Friday"
+	OnMonthDays(days []int) string          // "on the 1st and 15th"
+	OnYearDays(days []int) string           // "on day 1 and 100 of the year"
+	InMonths(months []time.Month) string    // "in June"
+	AtTimes(times []string) string          // "at 08:00 and 12:00"
+	OnlyPositions(positions []int) string   // "only the 1st of these"
+	Count(n int) string                     // "10 times"
+	Until(t time.Time, kind TimeKind) string
+}
+
+// Locales are the built-in locales by language code
+var Locales = map[string]Locale{
+	"en": English,
+	"de": German,
+}
+
+// Locale implementations for English and German
+var (
+	English Locale = english{}
+	German  Locale = german{}
+)
+
+// Describe words the rule in English, e.g. "every 2 weeks on Monday and
+// Wednesday, 10 times"
+func (r *RepeatRule) Describe() string {
+	return r.DescribeIn(English)
+}
+
+// DescribeIn words the rule in the given locale
+func (r *RepeatRule) DescribeIn(l Locale) string {
+	byDay, setPos := r.ByDay, r.BySetPos
+
+	// A position among the days of a single weekday is an ordinal weekday
+	if len(setPos) == 1 && len(byDay) == 1 && byDay[0].Ordinal == 0 {
+		byDay = []WeekdayNum{{Ordinal: setPos[0], Weekday: byDay[0].Weekday}}
+		setPos = nil
+	}
+
+	parts := []string{l.Every(r.Frequency, max(r.Interval, 1))}
+	if len(r.ByMonth) > 0 {
+		months := make([]time.Month, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = time.Month(m)
+		}
+		parts = append(parts, l.InMonths(months))
+	}
+	if len(r.ByYearDay) > 0 {
+		parts = append(parts, l.OnYearDays(r.ByYearDay))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, l.OnMonthDays(r.ByMonthDay))
+	}
+	if len(byDay) > 0 {
+		parts = append(parts, l.OnWeekdays(byDay))
+	}
+	if len(r.ByHour) > 0 || len(r.ByMinute) > 0 {
+		parts = append(parts, l.AtTimes(r.times()))
+	}
+
+	s := strings.Join(parts, " ")
+	if len(setPos) > 0 {
+		s += ", " + l.OnlyPositions(setPos)
+	}
+
+	switch {
+	case r.Occurences > 0:
+		s += ", " + l.Count(r.Occurences)
+	case !r.Until.IsZero():
+		s += ", " + l.Until(r.Until, r.UntilKind)
+	}
+	return s
+}
+
+// times lists the times of day of BYHOUR and BYMINUTE as hh:mm
+func (r *RepeatRule) times() []string {
+	hours, minutes := r.ByHour, r.ByMinute
+	if len(hours) == 0 {
+		hours = []int{0}
+	}
+	if len(minutes) == 0 {
+		minutes = []int{0}
+	}
+
+	var times []string
+	for _, h := range hours {
+		for _, m := range minutes {
+			times = append(times, fmt.Sprintf("%02d:%02d", h, m))
+		}
+	}
+	return times
+}
+
+// joinList joins items as in "a, b and c"
+func joinList(items []string, and string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + and + " " + items[len(items)-1]
+}
+
+func mapList[T any](items []T, f func(T) string) []string {
+	s := make([]string, len(items))
+	for i, item := range items {
+		s[i] = f(item)
+	}
+	return s
+}
+
+type english struct{}
+
+func (english) Every(f Frequency, interval int) string {
+	unit := [...]string{"day", "week", "month", "year"}[f]
+	if interval == 1 {
+		return "every " + unit
+	}
+	return fmt.Sprintf("every %d %ss", interval, unit)
+}
+
+// ordinal returns 1st, 2nd, 3rd, 4th, ...
+func (english) ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// position returns first, second, ..., last, 2nd to last, ...
+func (e english) position(n int) string {
+	words := [...]string{"first", "second", "third", "fourth", "fifth"}
+	switch {
+	case n == -1:
+		return "last"
+	case n < 0:
+		return e.ordinal(-n) + " to last"
+	case n <= len(words):
+		return words[n-1]
+	default:
+		return e.ordinal(n)
+	}
+}
+
+func (e english) OnWeekdays(days []WeekdayNum) string {
+	return "on " + joinList(mapList(days, func(d WeekdayNum) string {
+		if d.Ordinal == 0 {
+			return d.Weekday.String()
+		}
+		return "the " + e.position(d.Ordinal) + " " + d.Weekday.String()
+	}), "and")
+}
+
+func (e english) OnMonthDays(days []int) string {
+	return "on " + joinList(mapList(days, func(d int) string {
+		if d < 0 {
+			return "the " + e.position(d) + " day"
+		}
+		return "the " + e.ordinal(d)
+	}), "and")
+}
+
+func (e english) OnYearDays(days []int) string {
+	return "on " + joinList(mapList(days, func(d int) string {
+		if d < 0 {
+			return "the " + e.position(d) + " day"
+		}
+		return "the " + e.ordinal(d) + " day"
+	}), "and") + " of the year"
+}
+
+func (english) InMonths(months []time.Month) string {
+	return "in " + joinList(mapList(months, time.Month.String), "and")
+}
+
+func (english) AtTimes(times []string) string {
+	return "at " + joinList(times, "and")
+}
+
+func (e english) OnlyPositions(positions []int) string {
+	return "only the " + joinList(mapList(positions, e.position), "and") + " of these"
+}
+
+func (english) Count(n int) string {
+	switch n {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	}
+	return fmt.Sprintf("%d times", n)
+}
+
+func (english) Until(t time.Time, kind TimeKind) string {
+	switch kind {
+	case DateOnly:
+		return "until " + t.Format("January 2, 2006")
+	case LocalTime:
+		return "until " + t.Format("January 2, 2006 15:04")
+	default:
+		return "until " + t.UTC().Format("January 2, 2006 15:04") + " UTC"
+	}
+}
+
+type german struct{}
+
+var (
+	germanWeekdays = [...]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"}
+	germanMonths   = [...]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+)
+
+func (german) Every(f Frequency, interval int) string {
+	if interval == 1 {
+		return [...]string{"täglich", "wöchentlich", "monatlich", "jährlich"}[f]
+	}
+	unit := [...]string{"Tage", "Wochen", "Monate", "Jahre"}[f]
+	return fmt.Sprintf("alle %d %s", interval, unit)
+}
+
+// position returns the inflected ordinal used after "am": ersten,
+// zweiten, ..., letzten, vorletzten, 3.-letzten
+func (german) position(n int) string {
+	words := [...]string{"ersten", "zweiten", "dritten", "vierten", "fünften"}
+	switch {
+	case n == -1:
+		return "letzten"
+	case n == -2:
+		return "vorletzten"
+	case n < 0:
+		return strconv.Itoa(-n) + ".-letzten"
+	case n <= len(words):
+		return words[n-1]
+	default:
+		return strconv.Itoa(n) + "."
+	}
+}
+
+func (g german) OnWeekdays(days []WeekdayNum) string {
+	return "am " + joinList(mapList(days, func(d WeekdayNum) string {
+		if d.Ordinal == 0 {
+			return germanWeekdays[d.Weekday]
+		}
+		return g.position(d.Ordinal) + " " + germanWeekdays[d.Weekday]
+	}), "und")
+}
+
+func (g german) OnMonthDays(days []int) string {
+	return "am " + joinList(mapList(days, func(d int) string {
+		if d < 0 {
+			return g.position(d) + " Tag"
+		}
+		return strconv.Itoa(d) + "."
+	}), "und")
+}
+
+func (g german) OnYearDays(days []int) string {
+	return "am " + joinList(mapList(days, func(d int) string {
+		if d < 0 {
+			return g.position(d)
+		}
+		return strconv.Itoa(d) + "."
+	}), "und") + " Tag des Jahres"
+}
+
+func (german) InMonths(months []time.Month) string {
+	return "im " + joinList(mapList(months, func(m time.Month) string { return germanMonths[m-1] }), "und")
+}
+
+func (german) AtTimes(times []string) string {
+	return "um " + joinList(times, "und") + " Uhr"
+}
+
+func (g german) OnlyPositions(positions []int) string {
+	return "davon nur am " + joinList(mapList(positions, g.position), "und")
+}
+
+func (german) Count(n int) string {
+	if n == 1 {
+		return "einmal"
+	}
+	return fmt.Sprintf("%d Mal", n)
+}
+
+func (german) Until(t time.Time, kind TimeKind) string {
+	switch kind {
+	case DateOnly:
+		return "bis " + t.Format("02.01.2006")
+	case LocalTime:
+		return "bis " + t.Format("02.01.2006 15:04") + " Uhr"
+	default:
+		return "bis " + t.UTC().Format("02.01.2006 15:04") + " Uhr UTC"
+	}
+}
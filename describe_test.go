@@ -0,0 +1,66 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		rule    string
+		english string
+		german  string
+	}{
+		{"D1 #0", "every day", "täglich"},
+		{"W2 MO WE #10", "every 2 weeks on Monday and Wednesday, 10 times", "alle 2 Wochen am Montag und Mittwoch, 10 Mal"},
+		{"MP1 1+ MO 1- FR #0", "every month on the first Monday and the last Friday", "monatlich am ersten Montag und letzten Freitag"},
+		{"MP1 2+ #1", "every month, only the second of these, once", "monatlich, davon nur am zweiten, einmal"},
+		{"MD3 1 15 LD #0", "every 3 months on the 1st, the 15th and the last day", "alle 3 Monate am 1., 15. und letzten Tag"},
+		{"YM1 6 7 #2", "every year in June and July, twice", "jährlich im Juni und Juli, 2 Mal"},
+		{"YD1 1 100 #0", "every year on the 1st day and the 100th day of the year", "jährlich am 1. und 100. Tag des Jahres"},
+		{"D1 0800 #0", "every day at 08:00", "täglich um 08:00 Uhr"},
+		{"D1 20110610T160000Z", "every day, until June 10, 2011 16:00 UTC", "täglich, bis 10.06.2011 16:00 Uhr UTC"},
+		{"D1 20110610T160000", "every day, until June 10, 2011 16:00", "täglich, bis 10.06.2011 16:00 Uhr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := vcstoics.ParseRepeatRule(tt.rule, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rule.Describe(); got != tt.english {
+				t.Errorf("english: got %q, want %q", got, tt.english)
+			}
+			if got := rule.DescribeIn(vcstoics.German); got != tt.german {
+				t.Errorf("german: got %q, want %q", got, tt.german)
+			}
+		})
+	}
+}
+
+func TestConvertLogsRecurrence(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Weekly\r\nDTSTART:20110606T100000Z\r\nRRULE:W1 MO #4\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:Once\r\nDTSTART:20110606T100000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	var logs []string
+	err := vcstoics.ConvertWithOptions(strings.NewReader(input), &strings.Builder{}, vcstoics.Options{
+		Locale: vcstoics.Locales["de"],
+		Logf:   func(format string, v ...any) { logs = append(logs, fmt.Sprintf(format, v...)) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(logs) != 1 || logs[0] != "Weekly: wöchentlich am Montag, 4 Mal" {
+		t.Errorf("unexpected logs %q", logs)
+	}
+}
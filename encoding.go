@@ -0,0 +1,246 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Input encodings reported in Calendar.Encoding
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+)
+
+// sniffLength is how much of the input is looked at to guess its encoding
+const sniffLength = 512
+
+// newInputReader wraps r so that the parser can read it line by line.
+// Unless opts forces an encoding, it is detected from the byte order mark
+// or, failing that, from the position of NUL bytes typical of UTF-16
+// text. UTF-16 is converted to UTF-8 on the fly. 8-bit input is passed
+// through untouched, since quoted-printable values hold bytes of the same
+// charset; if it is not valid UTF-8 the legacy charset that best fits its
+// bytes is reported. It returns the encoding of the input.
+func newInputReader(r io.Reader, opts Options) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, sniffLength)
+
+	encoding := opts.InputEncoding
+	if encoding == "" || strings.EqualFold(encoding, "auto") {
+		head, err := br.Peek(sniffLength)
+		if err != nil && err != io.EOF {
+			return nil, "", err
+		}
+		encoding = sniffEncoding(head)
+
+		if encoding == EncodingUTF8 && !bytes.HasPrefix(head, []byte("\xef\xbb\xbf")) {
+			data, err := io.ReadAll(br)
+			if err != nil {
+				return nil, "", err
+			}
+			if utf8.Valid(unfold(data)) {
+				return bytes.NewReader(data), EncodingUTF8, nil
+			}
+
+			encoding = guessCharset(data)
+			opts.warnf("Input is not valid UTF-8, assuming %s", encoding)
+			return bytes.NewReader(data), encoding, nil
+		}
+	}
+
+	switch normalizeCharset(encoding) {
+	case "UTF8":
+		skipBOM(br, "\xef\xbb\xbf")
+		return br, EncodingUTF8, nil
+	case "UTF16LE":
+		skipBOM(br, "\xff\xfe")
+		return &utf16Reader{r: br, littleEndian: true}, EncodingUTF16LE, nil
+	case "UTF16BE":
+		skipBOM(br, "\xfe\xff")
+		return &utf16Reader{r: br}, EncodingUTF16BE, nil
+	case "UTF16", "UCS2":
+		// Big endian unless a byte order mark says otherwise
+		head, _ := br.Peek(2)
+		if bytes.HasPrefix(head, []byte("\xff\xfe")) {
+			opts.InputEncoding = EncodingUTF16LE
+		} else {
+			opts.InputEncoding = EncodingUTF16BE
+		}
+		return newInputReader(br, opts)
+	}
+
+	if !IsKnownCharset(encoding) {
+		return nil, "", fmt.Errorf("unsupported input encoding: %s", encoding)
+	}
+
+	return br, strings.ToUpper(encoding), nil
+}
+
+// sniffEncoding guesses the encoding of a document from its first bytes
+func sniffEncoding(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		return EncodingUTF8
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		return EncodingUTF16BE
+	}
+
+	// Without a BOM, mostly-ASCII UTF-16 text has a NUL in every other byte
+	var evenZeros, oddZeros int
+	for i, c := range head {
+		if c != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	pairs := len(head) / 2
+	switch {
+	case pairs == 0:
+		return EncodingUTF8
+	case evenZeros > pairs/2 && oddZeros == 0:
+		return EncodingUTF16BE
+	case oddZeros > pairs/2 && evenZeros == 0:
+		return EncodingUTF16LE
+	default:
+		return EncodingUTF8
+	}
+}
+
+// guessCandidates are the charsets considered for 8-bit input, in order
+// of preference when they fit equally well
+var guessCandidates = []string{"WINDOWS-1252", "ISO-8859-15", "ISO-8859-1", "ISO-8859-2"}
+
+// commonSymbols are non-letters that are likely to show up in calendar text
+const commonSymbols = "€£¥©®°±«»‘’‚“”„–—…•§µ·"
+
+// guessCharset picks the legacy charset under which the non-ASCII bytes
+// of data look most like text: letters next to other letters and common
+// symbols score, control characters and unmapped bytes are penalised.
+func guessCharset(data []byte) string {
+	best, bestScore := guessCandidates[0], math.MinInt
+	for _, name := range guessCandidates {
+		decode := charsets[normalizeCharset(name)]
+
+		score := 0
+		for i, c := range data {
+			if c < 0x80 {
+				continue
+			}
+
+			r, _ := utf8.DecodeRuneInString(decode([]byte{c}))
+			switch {
+			case r == utf8.RuneError:
+				score -= 10
+			case r < 0xa0:
+				score -= 5
+			case unicode.IsLetter(r):
+				score += 2
+				if i > 0 && isASCIILetter(data[i-1]) || i+1 < len(data) && isASCIILetter(data[i+1]) {
+					score++
+				}
+			case strings.ContainsRune(commonSymbols, r):
+				score++
+			}
+		}
+
+		if score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func skipBOM(br *bufio.Reader, bom string) {
+	if head, _ := br.Peek(len(bom)); string(head) == bom {
+		br.Discard(len(bom))
+	}
+}
+
+// utf16Reader transcodes a UTF-16 stream to UTF-8
+type utf16Reader struct {
+	r            *bufio.Reader
+	littleEndian bool
+	pending      []byte // UTF-8 output not returned yet
+	unit         *uint16
+	err          error // error of r, reported once pending is returned
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	if u.unit != nil {
+		unit := *u.unit
+		u.unit = nil
+		return unit, nil
+	}
+
+	var b [2]byte
+	n, err := io.ReadFull(u.r, b[:])
+	if err == io.ErrUnexpectedEOF && n == 1 {
+		// Dangling byte at the end of the input
+		return utf8.RuneError, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if u.littleEndian {
+		return uint16(b[1])<<8 | uint16(b[0]), nil
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.pending) < len(p) && u.err == nil {
+		unit, err := u.readUnit()
+		if err != nil {
+			u.err = err
+			break
+		}
+
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			low, err := u.readUnit()
+			switch {
+			case err != nil:
+				u.err = err
+				r = utf8.RuneError
+			default:
+				r = utf16.DecodeRune(r, rune(low))
+				if r == utf8.RuneError {
+					// Not a valid pair, the second unit stands on its own
+					u.unit = &low
+				}
+			}
+		}
+
+		u.pending = utf8.AppendRune(u.pending, r)
+	}
+
+	if len(u.pending) == 0 {
+		return 0, u.err
+	}
+
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+	return n, nil
+}
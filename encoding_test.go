@@ -0,0 +1,120 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"unicode/utf16"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+const encodingDoc = "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Party 🎉 ñ\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+func encodeUTF16(s string, littleEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+
+	var b []byte
+	for _, u := range units {
+		if littleEndian {
+			b = append(b, byte(u), byte(u>>8))
+		} else {
+			b = append(b, byte(u>>8), byte(u))
+		}
+	}
+	return b
+}
+
+func TestInputEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		force    string
+		encoding string
+	}{
+		{"utf-8", []byte(encodingDoc), "", vcstoics.EncodingUTF8},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), encodingDoc...), "", vcstoics.EncodingUTF8},
+		{"utf-16le bom", encodeUTF16(encodingDoc, true, true), "", vcstoics.EncodingUTF16LE},
+		{"utf-16be bom", encodeUTF16(encodingDoc, false, true), "", vcstoics.EncodingUTF16BE},
+		{"utf-16le", encodeUTF16(encodingDoc, true, false), "", vcstoics.EncodingUTF16LE},
+		{"utf-16be", encodeUTF16(encodingDoc, false, false), "", vcstoics.EncodingUTF16BE},
+		{"forced utf-16le", encodeUTF16(encodingDoc, true, false), "utf-16le", vcstoics.EncodingUTF16LE},
+		{"forced utf-16", encodeUTF16(encodingDoc, true, true), "UTF-16", vcstoics.EncodingUTF16LE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := vcstoics.ParseVCalendarWithOptions(bytes.NewReader(tt.input), vcstoics.Options{
+				InputEncoding: tt.force,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cal.Encoding != tt.encoding {
+				t.Errorf("encoding = %q, want %q", cal.Encoding, tt.encoding)
+			}
+			if got := cal.Components[0].Value("SUMMARY"); got != "Party 🎉 ñ" {
+				t.Errorf("summary = %q", got)
+			}
+		})
+	}
+}
+
+func TestInputEncodingUnsupported(t *testing.T) {
+	_, err := vcstoics.ParseVCalendarWithOptions(bytes.NewReader([]byte(encodingDoc)), vcstoics.Options{
+		InputEncoding: "EBCDIC",
+	})
+	if err == nil {
+		t.Errorf("expected an error for an unsupported encoding")
+	}
+}
+
+func TestInputEncodingGuess(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  []byte
+		force    string
+		encoding string
+		want     string
+	}{
+		{"windows-1252", []byte("Caf\xe9 \x93Z\xfcrich\x94"), "", "WINDOWS-1252", "Café “Zürich”"},
+		{"iso-8859-2", []byte("Za\xbf\xf3\xb3\xe6 g\xea\xb6l\xb1 ja\xbc\xf1"), "", "ISO-8859-2", "Zażółć gęślą jaźń"},
+		{"forced", []byte("Caf\xe9"), "ISO-8859-1", "ISO-8859-1", "Café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:" + string(tt.summary) + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			var warnings []string
+			cal, err := vcstoics.ParseVCalendarWithOptions(bytes.NewReader([]byte(input)), vcstoics.Options{
+				InputEncoding: tt.force,
+				Warnf: func(format string, v ...any) {
+					warnings = append(warnings, fmt.Sprintf(format, v...))
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cal.Encoding != tt.encoding {
+				t.Errorf("encoding = %q, want %q", cal.Encoding, tt.encoding)
+			}
+			if got := cal.Components[0].Value("SUMMARY"); got != tt.want {
+				t.Errorf("summary = %q, want %q", got, tt.want)
+			}
+
+			// Only a guess is worth a warning
+			if guessed := tt.force == ""; guessed != (len(warnings) == 1) {
+				t.Errorf("unexpected warnings: %q", warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultUIDDomain is the domain of generated UIDs when none is configured
+const DefaultUIDDomain = "vcs-to-ics"
+
+// Entry is a calendar entry ready to be written as a VEVENT or VTODO
+type Entry struct {
+	IsEvent     bool
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	DTStart     string
+	DTEnd       string
+	AllDay      bool // written as dates, whatever the times of DTSTART and DTEND
+	RRule       string
+	DTStamp     string
+	Sequence    string
+	Due         string
+	Status      string
+	Completed   string // date-time a todo was completed
+	Percent     string // PERCENT-COMPLETE of a todo
+	Priority    string
+	Class       string
+	Transp      string
+	Categories  []string
+	Resources   []string
+	URL         string
+	Geo         string // latitude and longitude as "lat;lon"
+	RelatedTo   []Relation
+	Attachments []*Attachment
+	Unknown     []*Property // properties that are not converted
+	Organizer   *Attendee
+	Attendees   []*Attendee
+	Alarms      []*Alarm
+	TZID        string // time zone of local date-times, if known
+	ExDate      []string
+	RDate       []string
+	ExRule      []string
+}
+
+// NewEntry builds an Entry from a parsed VEVENT or VTODO component
+func NewEntry(comp *Component, opts Options) *Entry {
+	entry := &Entry{
+		IsEvent:     comp.Name == "VEVENT",
+		UID:         comp.Value("UID"),
+		Summary:     comp.Text("SUMMARY"),
+		Description: comp.Text("DESCRIPTION"),
+		Location:    comp.Text("LOCATION"),
+		DTStart:     comp.Value("DTSTART"),
+		DTEnd:       comp.Value("DTEND"),
+		RRule:       comp.Value("RRULE"),
+		DTStamp:     comp.Value("LAST-MODIFIED"),
+		Sequence:    comp.Value("SEQUENCE"),
+		Due:         comp.Value("DUE"),
+		Status:      comp.Value("STATUS"),
+		Completed:   comp.Value("COMPLETED"),
+		Percent:     comp.Value("PERCENT-COMPLETE"),
+		Priority:    comp.Value("PRIORITY"),
+		Class:       comp.Value("CLASS"),
+		Transp:      comp.Value("TRANSP"),
+	}
+
+	if entry.DTStamp == "" {
+		entry.DTStamp = comp.Value("DTSTAMP")
+	}
+
+	entry.Categories = textList(comp.GetAll("CATEGORIES"))
+	entry.Resources = textList(comp.GetAll("RESOURCES"))
+	entry.URL = strings.TrimSpace(comp.Value("URL"))
+
+	if p := comp.Get("GEO"); p != nil {
+		geo, err := parseGeo(p.Value)
+		if err != nil {
+			opts.warnf("Ignoring GEO: %v", err)
+		}
+		entry.Geo = geo
+	}
+
+	for _, p := range comp.GetAll("RELATED-TO") {
+		if uid := strings.TrimSpace(p.Value); uid != "" {
+			entry.RelatedTo = append(entry.RelatedTo, Relation{
+				UID:  uid,
+				Type: strings.ToUpper(strings.TrimSpace(p.Params.Get("RELTYPE"))),
+			})
+		}
+	}
+
+	for _, p := range comp.GetAll("EXDATE") {
+		entry.ExDate = append(entry.ExDate, p.Value)
+	}
+	for _, p := range comp.GetAll("RDATE") {
+		entry.RDate = append(entry.RDate, p.Value)
+	}
+	for _, p := range comp.GetAll("EXRULE") {
+		entry.ExRule = append(entry.ExRule, p.Value)
+	}
+
+	for _, p := range comp.GetAll("ATTACH") {
+		attachment, err := ParseAttachment(p)
+		if err != nil {
+			opts.warnf("Ignoring attachment: %v", err)
+			continue
+		}
+		entry.Attachments = append(entry.Attachments, attachment)
+	}
+
+	// The first owner organizes the entry, as does an ORGANIZER property
+	for _, p := range comp.Properties {
+		if p.Name != "ATTENDEE" && p.Name != "ORGANIZER" {
+			continue
+		}
+		attendee, err := ParseAttendee(p)
+		if err != nil {
+			opts.warnf("Ignoring attendee: %v", err)
+			continue
+		}
+		if attendee.IsOrganizer() && entry.Organizer == nil {
+			entry.Organizer = attendee
+			continue
+		}
+		entry.Attendees = append(entry.Attendees, attendee)
+	}
+
+	for _, p := range comp.Properties {
+		if !isAlarm(p.Name) {
+			continue
+		}
+		alarm, err := ParseAlarm(p)
+		if err != nil {
+			opts.warnf("Ignoring alarm: %v", err)
+			continue
+		}
+		if alarm.Procedure != "" {
+			opts.warnf("Procedure alarm %s converted to a display alarm", alarm.Procedure)
+		}
+		entry.Alarms = append(entry.Alarms, alarm)
+	}
+
+	entry.Unknown = unknownProperties(comp)
+	entry.applySymbian(comp)
+
+	if entry.UID == "" {
+		entry.UID = GenerateUID(comp, opts.UIDDomain)
+	}
+
+	return entry
+}
+
+// Relation is a RELATED-TO property, linking an entry to another by UID
+type Relation struct {
+	UID  string
+	Type string // PARENT, CHILD or SIBLING, PARENT if empty
+}
+
+// textList returns the values of semicolon separated vCalendar lists
+// such as CATEGORIES, in the order they appear
+func textList(props []*Property) []string {
+	var values []string
+	for _, p := range props {
+		for _, v := range splitValue(p.Value) {
+			if v = strings.TrimSpace(UnescapeText(v)); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseGeo parses a vCalendar GEO value, "lat,lon" or "lat;lon", into the
+// RFC 5545 form "lat;lon"
+func parseGeo(value string) (string, error) {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' })
+	if len(parts) != 2 {
+		return "", fmt.Errorf("expected latitude and longitude in %q", value)
+	}
+
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errLat != nil || errLon != nil || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return "", fmt.Errorf("invalid coordinates %q", value)
+	}
+
+	return strings.TrimSpace(parts[0]) + ";" + strings.TrimSpace(parts[1]), nil
+}
+
+// GenerateUID derives a UID from the content of a component, so that
+// converting the same entry twice yields the same UID
+func GenerateUID(comp *Component, domain string) string {
+	if domain == "" {
+		domain = DefaultUIDDomain
+	}
+
+	h := sha256.New()
+	writeComponent(h, comp)
+	return hex.EncodeToString(h.Sum(nil)[:16]) + "@" + domain
+}
+
+// writeComponent feeds a canonical form of the component to w
+func writeComponent(w io.Writer, comp *Component) {
+	w.Write([]byte("BEGIN:" + comp.Name + "\n"))
+	for _, p := range comp.Properties {
+		w.Write([]byte(p.Name))
+		for _, param := range p.Params {
+			w.Write([]byte(";" + param.Name + "=" + strings.Join(param.Values, ",")))
+		}
+		w.Write([]byte(":" + p.Value + "\n"))
+	}
+	for _, child := range comp.Components {
+		writeComponent(w, child)
+	}
+	w.Write([]byte("END:" + comp.Name + "\n"))
+}
+
+// startType describes how the DTSTART of e is written, given the time
+// zone its TZID refers to. day holds the dates of all-day entries.
+func (e *Entry) startType(day *allDaySpan, tz *TimeZone) startType {
+	if day != nil {
+		return startType{start: day.start, allDay: true, tz: tz, tzid: e.TZID}
+	}
+	return e.timeType(e.DTStart, tz)
+}
+
+// timeType describes how a date-time of e, such as DTSTART or DUE, is
+// written
+func (e *Entry) timeType(value string, tz *TimeZone) startType {
+	start, _ := ParseDate(value)
+	return startType{
+		start: start,
+		utc:   strings.HasSuffix(value, "Z"),
+		tz:    tz,
+		tzid:  e.TZID,
+	}
+}
+
+// allDayStart returns the startType of e, deciding whether it is an
+// all-day entry
+func (e *Entry) allDayStart(tz *TimeZone, policy AllDayPolicy) startType {
+	if day, ok := e.allDaySpan(tz, policy); ok {
+		return e.startType(&day, tz)
+	}
+	return e.startType(nil, tz)
+}
+
+// recurs reports whether e has a recurrence rule or dates
+func (e *Entry) recurs() bool {
+	return e.RRule != "" || len(e.RDate) > 0
+}
+
+// repeatRule parses the RRULE of e, or returns nil if it has none or it
+// cannot be parsed. Times the rule cannot keep are reported to warnf.
+func (e *Entry) repeatRule(st startType, warnf func(string, ...any)) *RepeatRule {
+	if e.RRule == "" {
+		return nil
+	}
+	rule, err := ParseRepeatRule(e.RRule, true)
+	if err != nil {
+		return nil
+	}
+	if len(rule.IgnoredTimes) > 0 {
+		warnf("RRULE %s of %s: times %s do not combine into BYHOUR and BYMINUTE, repeating at the time of DTSTART only",
+			e.RRule, e.UID, strings.Join(rule.IgnoredTimes, " "))
+	}
+	rule.matchStart(e.DTStart, st.allDay, st.tz)
+
+	// Positions without a weekday refer to the weekday of DTSTART
+	if len(rule.BySetPos) > 0 && len(rule.ByDay) == 0 && !st.start.IsZero() {
+		rule.ByDay = []WeekdayNum{{Weekday: st.start.Weekday()}}
+	}
+	return rule
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestNewEntryUID(t *testing.T) {
+	parse := func(body string) *vcstoics.Component {
+		input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + body + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+		cal, err := vcstoics.ParseVCalendar(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cal.Components[0]
+	}
+
+	withUID := parse("UID:fv84f234r8fojq30ncn4\r\nSUMMARY:a\r\nDTSTART:20110605T140000Z\r\n")
+	if got := vcstoics.NewEntry(withUID, vcstoics.Options{}).UID; got != "fv84f234r8fojq30ncn4" {
+		t.Errorf("UID = %q, want the source UID", got)
+	}
+
+	a := parse("SUMMARY:a\r\nDTSTART:20110605T140000Z\r\n")
+	b := parse("SUMMARY:b\r\nDTSTART:20110605T140000Z\r\n")
+	opts := vcstoics.Options{UIDDomain: "example.com"}
+
+	uidA := vcstoics.NewEntry(a, opts).UID
+	if !strings.HasSuffix(uidA, "@example.com") {
+		t.Errorf("UID %q does not use the configured domain", uidA)
+	}
+	if again := vcstoics.NewEntry(a, opts).UID; again != uidA {
+		t.Errorf("UID is not stable: %q != %q", again, uidA)
+	}
+	if uidB := vcstoics.NewEntry(b, opts).UID; uidB == uidA {
+		t.Errorf("different entries share UID %q", uidA)
+	}
+}
+
+func TestConvertTodo(t *testing.T) {
+	tests := []struct {
+		name  string
+		props string
+		want  string
+	}{
+		{
+			"all properties",
+			"DTSTART:20110601T090000\r\nDUE:20110608T170000\r\nCOMPLETED:20110603T100000\r\nSTATUS:COMPLETED\r\n" +
+				"PRIORITY:1\r\nCLASS:CONFIDENTIAL\r\nDESCRIPTION:Call\\, then write\r\nLOCATION:Office\r\n" +
+				"CATEGORIES:BUSINESS;PHONE CALL\r\nDALARM:20110601T083000\r\n",
+			"BEGIN:VTODO\nUID:1\nDTSTAMP:20250520T140140Z\nSEQUENCE:0\n" +
+				"DTSTART;TZID=+02:20110601T090000\nDUE;TZID=+02:20110608T170000\n" +
+				"COMPLETED:20110603T080000Z\nSTATUS:COMPLETED\nPRIORITY:1\nCLASS:CONFIDENTIAL\nPERCENT-COMPLETE:100\n" +
+				"SUMMARY:Report\nDESCRIPTION:Call\\, then write\nLOCATION:Office\nCATEGORIES:BUSINESS,PHONE CALL\n" +
+				"BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Report\nTRIGGER:-PT30M\nEND:VALARM\n" +
+				"END:VTODO\n",
+		},
+		{
+			"recurring without start",
+			"DUE:20110608T170000\r\nRRULE:W1 #4\r\nEXDATE:20110615T170000\r\n",
+			"BEGIN:VTODO\nUID:1\nDTSTAMP:20250520T140140Z\nSEQUENCE:0\n" +
+				"DTSTART;TZID=+02:20110608T170000\nDUE;TZID=+02:20110608T170000\nSUMMARY:Report\n" +
+				"RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=4\nEXDATE;TZID=+02:20110615T170000\n" +
+				"END:VTODO\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nTZ:+02\r\nBEGIN:VTODO\r\nUID:1\r\nSUMMARY:Report\r\n" + tt.props +
+				"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+			if got := convertString(t, input, vcstoics.Options{}); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertClassification(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20110608T060000Z\r\n" +
+		"CATEGORIES:MEETING;PHONE CALL\r\nCATEGORIES:Work\\, urgent\r\nRESOURCES:PROJECTOR;VCR\r\n" +
+		"URL:http://example.com/meeting\r\nGEO:52.52,13.405\r\n" +
+		"RELATED-TO:parent@example.com\r\nRELATED-TO;RELTYPE=CHILD:child@example.com\r\n" +
+		"END:VEVENT\r\nBEGIN:VTODO\r\nUID:2\r\nCATEGORIES:ERRAND\r\nGEO:-33.86;151.2\r\n" +
+		"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+	want := "BEGIN:VEVENT\nUID:1\n" +
+		"CATEGORIES:MEETING,PHONE CALL,Work\\, urgent\nRESOURCES:PROJECTOR,VCR\n" +
+		"URL:http://example.com/meeting\nGEO:52.52;13.405\n" +
+		"RELATED-TO:parent@example.com\nRELATED-TO;RELTYPE=CHILD:child@example.com\n" +
+		"DTSTART:20110608T060000Z\nDTSTAMP:20250520T140140Z\nEND:VEVENT\n" +
+		"BEGIN:VTODO\nUID:2\nDTSTAMP:20250520T140140Z\nSEQUENCE:0\n" +
+		"CATEGORIES:ERRAND\nGEO:-33.86;151.2\nEND:VTODO\n"
+	if got := convertString(t, input, vcstoics.Options{}); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertInvalidGeo(t *testing.T) {
+	for _, geo := range []string{"52.52", "north,east", "95,10", "1,2,3"} {
+		input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20110608T060000Z\r\nGEO:" + geo +
+			"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+		var warnings int
+		entries := convertString(t, input, vcstoics.Options{
+			Warnf: func(string, ...any) { warnings++ },
+		})
+		if got := propertyLines(entries, "GEO"); got != "" || warnings != 1 {
+			t.Errorf("GEO:%s: expected it to be dropped with a warning, got %d warnings and %q", geo, warnings, got)
+		}
+	}
+}
+
+func TestConvertDTStamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  string
+		stamp    string
+		want     string
+		warnings int
+	}{
+		{"utc", "", "LAST-MODIFIED:20110601T130546Z\r\n", "DTSTAMP:20110601T130546Z\n", 0},
+		{"source dtstamp", "", "DTSTAMP:20110601T130546Z\r\n", "DTSTAMP:20110601T130546Z\n", 0},
+		{"local in a known zone", "TZ:+02\r\n", "LAST-MODIFIED:20110601T130546\r\n", "DTSTAMP:20110601T110546Z\n", 0},
+		{"local in an unknown zone", "", "LAST-MODIFIED:20110601T130546\r\n", "DTSTAMP:20250520T140140Z\n", 1},
+		{"none", "", "", "DTSTAMP:20250520T140140Z\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\n" + tt.headers + "BEGIN:VEVENT\r\nUID:1\r\nDTSTART:20110608T060000Z\r\n" +
+				tt.stamp + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			var warnings int
+			entries := convertString(t, input, vcstoics.Options{
+				Warnf: func(string, ...any) { warnings++ },
+			})
+			if got := propertyLines(entries, "DTSTAMP"); got != tt.want || warnings != tt.warnings {
+				t.Errorf("got %q with %d warnings, want %q with %d", got, warnings, tt.want, tt.warnings)
+			}
+		})
+	}
+}
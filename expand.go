@@ -0,0 +1,544 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"time"
+)
+
+// maxEmptyPeriods ends the expansion of rules that never match, such as
+// the 30th of February, instead of looking for occurrences forever
+const maxEmptyPeriods = 1000
+
+// Occurrence is a single instance of a calendar entry. Times in UTC or in
+// a known time zone are instants, floating times and the days of all-day
+// entries are wall clock times kept in the UTC fields of a time.Time.
+type Occurrence struct {
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+	Floating bool // Start and End are wall clock times of no particular zone
+}
+
+// Expansion describes the entry a RepeatRule belongs to
+type Expansion struct {
+	Start    time.Time     // DTSTART, a wall clock time unless in UTC
+	Duration time.Duration // exact length of every occurrence
+	TimeZone *TimeZone     // zone of a local Start, nil for UTC and floating times
+	AllDay   bool
+	Floating bool        // Start is a local time of no particular zone
+	ExDates  []time.Time // cancelled occurrences, given like Start
+	RDates   []time.Time // additional occurrences, given like Start
+
+	// ClampMonthEnd moves occurrences on days a month does not have, such
+	// as the 31st or the 29th of February, to its last day as many
+	// vCalendar devices do. RFC 5545 skips them, which is the default.
+	ClampMonthEnd bool
+}
+
+// Occurrences yields the occurrences of the rule that overlap the period
+// from from to to, in order. COUNT and UNTIL count from Start, however far
+// before from it is. Occurrences in a time zone keep their wall clock time
+// across daylight saving time changes. A nil rule only yields Start and
+// the RDates.
+func (r *RepeatRule) Occurrences(x Expansion, from, to time.Time) iter.Seq[Occurrence] {
+	return func(yield func(Occurrence) bool) {
+		tz := x.TimeZone
+		if x.AllDay {
+			tz = nil
+		}
+
+		starts := slices.Values([]time.Time{x.Start})
+		if r != nil {
+			starts = r.occurrences(x.Start, tz, true, x.ClampMonthEnd)
+		}
+		starts = mergeTimes(starts, slices.Values(sortTimes(slices.Clone(x.RDates))))
+
+		var prev time.Time
+		for wall := range starts {
+			// An RDATE may repeat an occurrence of the rule
+			if wall.Equal(prev) || slices.ContainsFunc(x.ExDates, wall.Equal) {
+				continue
+			}
+			prev = wall
+
+			occ := Occurrence{
+				Start:    wall,
+				AllDay:   x.AllDay,
+				Floating: x.Floating && !x.AllDay,
+			}
+			if tz != nil {
+				occ.Start = tz.ToUTC(wall)
+			}
+			occ.End = occ.Start.Add(x.Duration)
+
+			if !occ.Start.Before(to) {
+				return
+			}
+			if occ.End.After(from) || !occ.Start.Before(from) {
+				if !yield(occ) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// expand yields the wall clock times of the occurrences of the rule, in
+// order, starting with start itself. Wall clock times are kept in the UTC
+// fields of a time.Time. tz is only needed to compare with an UNTIL in UTC
+// and must be nil for rules starting in UTC or at a floating time.
+func (r *RepeatRule) expand(start time.Time, tz *TimeZone) iter.Seq[time.Time] {
+	return r.occurrences(start, tz, true, false)
+}
+
+// occurrences is expand, but start is only included if it matches the
+// rule unless alwaysStart is set. With clamp, days missing from a month
+// move to its last day.
+func (r *RepeatRule) occurrences(start time.Time, tz *TimeZone, alwaysStart, clamp bool) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		count := 0
+		emit := func(t time.Time) bool {
+			if r.pastUntil(t, tz) {
+				return false
+			}
+			count++
+			return yield(t) && (r.Occurences == 0 || count < r.Occurences)
+		}
+
+		// DTSTART is always the first occurrence of an RRULE
+		if alwaysStart || slices.ContainsFunc(r.periodTimes(start, 0, clamp), start.Equal) {
+			if !emit(start) {
+				return
+			}
+		}
+
+		interval := max(r.Interval, 1)
+		empty := 0
+		for n := 0; empty < maxEmptyPeriods; n += interval {
+			times := r.periodTimes(start, n, clamp)
+			if len(times) > 0 && times[0].Year() > 9999 {
+				return
+			}
+
+			found := false
+			for _, t := range times {
+				if !t.After(start) {
+					continue
+				}
+				found = true
+				if !emit(t) {
+					return
+				}
+			}
+
+			if found {
+				empty = 0
+			} else {
+				empty++
+			}
+		}
+	}
+}
+
+// pastUntil reports whether the wall clock time t is after UNTIL
+func (r *RepeatRule) pastUntil(t time.Time, tz *TimeZone) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+
+	switch r.UntilKind {
+	case DateOnly:
+		return dateOf(t).After(dateOf(r.Until))
+	case LocalTime:
+		return t.After(r.Until)
+	default:
+		if tz != nil {
+			t = tz.ToUTC(t)
+		}
+		return t.After(r.Until)
+	}
+}
+
+// periodTimes returns the sorted occurrences within the n-th period after
+// the one of start, BYSETPOS applied
+func (r *RepeatRule) periodTimes(start time.Time, n int, clamp bool) []time.Time {
+	var times []time.Time
+	for _, d := range r.periodDates(dateOf(start), n, clamp) {
+		times = append(times, r.timesOfDay(d, start)...)
+	}
+
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	times = slices.CompactFunc(times, time.Time.Equal)
+
+	if len(r.BySetPos) == 0 {
+		return times
+	}
+
+	var selected []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(times) + pos
+		}
+		if i >= 0 && i < len(times) {
+			selected = append(selected, times[i])
+		}
+	}
+	slices.SortFunc(selected, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(selected, time.Time.Equal)
+}
+
+// timesOfDay returns the times of the occurrences on day d
+func (r *RepeatRule) timesOfDay(d, start time.Time) []time.Time {
+	hours, minutes := r.ByHour, r.ByMinute
+	if len(hours) == 0 {
+		hours = []int{start.Hour()}
+	}
+	if len(minutes) == 0 {
+		minutes = []int{start.Minute()}
+	}
+
+	var times []time.Time
+	for _, h := range hours {
+		for _, m := range minutes {
+			times = append(times, time.Date(d.Year(), d.Month(), d.Day(), h, m, start.Second(), 0, time.UTC))
+		}
+	}
+	return times
+}
+
+// periodDates returns the days of the n-th period after the one of start
+// that match the rule
+func (r *RepeatRule) periodDates(start time.Time, n int, clamp bool) []time.Time {
+	var dates []time.Time
+
+	switch r.Frequency {
+	case Daily:
+		d := start.AddDate(0, 0, n)
+		if r.matchesDay(d) && r.matchesWeekday(d) {
+			dates = append(dates, d)
+		}
+
+	case Weekly:
+		// Weeks start on Monday
+		monday := start.AddDate(0, 0, -(int(start.Weekday())+6)%7+7*n)
+		weekdays := r.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []WeekdayNum{{Weekday: start.Weekday()}}
+		}
+		for i := range 7 {
+			d := monday.AddDate(0, 0, i)
+			if matchesAnyWeekday(d, weekdays) && r.matchesMonth(d) {
+				dates = append(dates, d)
+			}
+		}
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(first) {
+			dates = r.monthDates(first, start, clamp)
+		}
+
+	case Yearly:
+		year := start.Year() + n
+		switch {
+		case len(r.ByYearDay) > 0:
+			for _, d := range yearDays(year, r.ByYearDay) {
+				if r.matchesMonth(d) && r.matchesDay(d) && r.matchesWeekday(d) {
+					dates = append(dates, d)
+				}
+			}
+		case len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0:
+			// Ordinals count within the year
+			first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			dates = weekdaysBetween(first, first.AddDate(1, 0, -1), r.ByDay)
+		default:
+			months := r.ByMonth
+			if len(months) == 0 {
+				months = []int{int(start.Month())}
+			}
+			for _, m := range months {
+				first := time.Date(year, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+				dates = append(dates, r.monthDates(first, start, clamp)...)
+			}
+		}
+	}
+
+	return dates
+}
+
+// monthDates returns the days of the month starting at first that match
+// BYMONTHDAY and BYDAY, or the day of the month of start without either.
+// Months too short for a day have none, or their last day with clamp.
+func (r *RepeatRule) monthDates(first, start time.Time, clamp bool) []time.Time {
+	last := first.AddDate(0, 1, -1)
+
+	var dates []time.Time
+	switch {
+	case len(r.ByDay) > 0:
+		for _, d := range weekdaysBetween(first, last, r.ByDay) {
+			if len(r.ByMonthDay) == 0 || r.matchesDay(d) {
+				dates = append(dates, d)
+			}
+		}
+	case len(r.ByMonthDay) > 0:
+		beyond := slices.ContainsFunc(r.ByMonthDay, func(day int) bool { return day > last.Day() })
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			if r.matchesDay(d) || clamp && beyond && d.Equal(last) {
+				dates = append(dates, d)
+			}
+		}
+	default:
+		day := start.Day()
+		if day > last.Day() {
+			if !clamp {
+				break
+			}
+			day = last.Day()
+		}
+		dates = append(dates, first.AddDate(0, 0, day-1))
+	}
+	return dates
+}
+
+func (r *RepeatRule) matchesMonth(d time.Time) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, int(d.Month()))
+}
+
+// matchesDay checks BYMONTHDAY, where negative days count from the end
+func (r *RepeatRule) matchesDay(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return slices.Contains(r.ByMonthDay, d.Day()) || slices.Contains(r.ByMonthDay, d.Day()-daysInMonth-1)
+}
+
+// matchesWeekday checks BYDAY, ignoring ordinals
+func (r *RepeatRule) matchesWeekday(d time.Time) bool {
+	return len(r.ByDay) == 0 || matchesAnyWeekday(d, r.ByDay)
+}
+
+func matchesAnyWeekday(d time.Time, weekdays []WeekdayNum) bool {
+	return slices.ContainsFunc(weekdays, func(w WeekdayNum) bool { return w.Weekday == d.Weekday() })
+}
+
+// weekdaysBetween returns the days from first to last, inclusive, that
+// match the weekdays. Ordinals count within that range.
+func weekdaysBetween(first, last time.Time, weekdays []WeekdayNum) []time.Time {
+	var dates []time.Time
+	for _, w := range weekdays {
+		var matches []time.Time
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == w.Weekday {
+				matches = append(matches, d)
+			}
+		}
+
+		switch {
+		case w.Ordinal == 0:
+			dates = append(dates, matches...)
+		case w.Ordinal > 0 && w.Ordinal <= len(matches):
+			dates = append(dates, matches[w.Ordinal-1])
+		case w.Ordinal < 0 && -w.Ordinal <= len(matches):
+			dates = append(dates, matches[len(matches)+w.Ordinal])
+		}
+	}
+	return dates
+}
+
+// yearDays resolves BYYEARDAY entries of a year to dates, skipping days
+// past the end of the year
+func yearDays(year int, days []int) []time.Time {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	length := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+
+	var dates []time.Time
+	for _, day := range days {
+		if day < 0 {
+			day += length + 1
+		}
+		if day >= 1 && day <= length {
+			dates = append(dates, first.AddDate(0, 0, day-1))
+		}
+	}
+	return dates
+}
+
+// dateOf strips the time of day
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Expansion returns the recurrence rule of the entry, nil if it has none,
+// and what it is expanded against. tz is the time zone TZID refers to.
+// To-dos without a start date recur from their due date.
+func (e *Entry) Expansion(tz *TimeZone, opts Options) (*RepeatRule, Expansion, error) {
+	if e.DTStart == "" {
+		if e.IsEvent || e.Due == "" {
+			return nil, Expansion{}, fmt.Errorf("no start date specified")
+		}
+		todo := *e
+		todo.DTStart, todo.DTEnd = e.Due, e.Due
+		e = &todo
+	}
+	if _, err := ParseDate(e.DTStart); err != nil {
+		return nil, Expansion{}, fmt.Errorf("invalid start date: %w", err)
+	}
+
+	day, allDay := e.allDaySpan(tz, opts.AllDay)
+	st := e.startType(nil, tz)
+	if allDay {
+		st = e.startType(&day, tz)
+	}
+	rule := e.repeatRule(st, opts.warnf)
+
+	exdates, rdates, err := e.recurrenceDates(st, rule, opts.warnf)
+	if err != nil {
+		return nil, Expansion{}, err
+	}
+
+	x := Expansion{
+		Start:         st.start,
+		TimeZone:      st.expansionZone(),
+		AllDay:        allDay,
+		Floating:      !st.utc && st.tz == nil,
+		ExDates:       exdates,
+		RDates:        rdates,
+		ClampMonthEnd: opts.ClampMonthEnd,
+	}
+
+	switch {
+	case allDay:
+		x.Duration = time.Duration(day.days) * 24 * time.Hour
+	case e.DTEnd != "" && e.DTEnd != e.DTStart:
+		values, err := parseDateList(e.DTEnd)
+		if err != nil || len(values) != 1 {
+			return nil, Expansion{}, fmt.Errorf("invalid end date: %s", e.DTEnd)
+		}
+		start, end := x.Start, st.wall(values[0])
+		if x.TimeZone != nil {
+			start, end = x.TimeZone.ToUTC(start), x.TimeZone.ToUTC(end)
+		}
+		x.Duration = max(end.Sub(start), 0)
+	}
+
+	return rule, x, nil
+}
+
+// Instance is an occurrence of a calendar entry
+type Instance struct {
+	Entry    *Entry
+	TimeZone *TimeZone // zone of the local times of Entry, if known
+	Occurrence
+}
+
+// Flatten returns a copy of the entry that describes just this occurrence,
+// with its own UID and without recurrence properties. Times that are not
+// floating are written in UTC.
+func (in Instance) Flatten() *Entry {
+	e := *in.Entry
+	e.RRule, e.ExDate, e.RDate, e.ExRule = "", nil, nil, nil
+	e.TZID = ""
+
+	kind := UTCTime
+	switch {
+	case in.AllDay:
+		kind = LocalTime
+		e.AllDay = true
+		e.DTStart = FormatTime(in.Start, kind)
+		e.DTEnd = FormatTime(in.End, kind)
+	case in.Floating:
+		kind = LocalTime
+		fallthrough
+	default:
+		e.DTStart = FormatTime(in.Start, kind)
+		e.DTEnd = FormatTime(in.End, kind)
+	}
+
+	// A todo keeps the distance from its start to DUE. One that recurs
+	// from DUE has no start of its own.
+	switch {
+	case e.IsEvent || e.Due == "":
+	case in.Entry.DTStart == "":
+		e.Due = e.DTStart
+		e.DTStart, e.DTEnd = "", ""
+	default:
+		e.Due = ""
+		st := in.Entry.timeType(in.Entry.DTStart, in.TimeZone)
+		if offset, err := st.trigger(in.Entry.Due); err == nil {
+			e.Due = FormatTime(in.Start.Add(time.Duration(offset)*time.Second), kind)
+		}
+	}
+
+	// Alarms keep their distance to the start, or DUE for todos without one
+	anchor := in.Entry.DTStart
+	if anchor == "" {
+		anchor = in.Entry.Due
+	}
+	if _, err := ParseDate(anchor); err == nil {
+		st := in.Entry.timeType(anchor, in.TimeZone)
+		e.Alarms = nil
+		for _, a := range in.Entry.Alarms {
+			alarm := *a
+			if a.RunTime != "" {
+				if offset, err := st.trigger(a.RunTime); err == nil {
+					alarm.RunTime = FormatTime(in.Start.Add(time.Duration(offset)*time.Second), kind)
+				}
+			}
+			e.Alarms = append(e.Alarms, &alarm)
+		}
+	}
+
+	if in.AllDay {
+		e.UID = in.Entry.UID + "-" + FormatTime(in.Start, DateOnly)
+	} else {
+		e.UID = in.Entry.UID + "-" + FormatTime(in.Start, kind)
+	}
+	return &e
+}
+
+// Expand reads a vCalendar 1.0 document from in and returns the
+// occurrences of its entries that overlap the period from from to to,
+// sorted by start
+func Expand(in io.Reader, from, to time.Time, opts Options) ([]Instance, error) {
+	cal, err := ParseVCalendarWithOptions(in, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	tz, err := resolveTimeZone(cal, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	for _, comp := range cal.Components {
+		if comp.Name != "VEVENT" && comp.Name != "VTODO" {
+			continue
+		}
+
+		entry := NewEntry(comp, opts)
+		if tz != nil {
+			entry.TZID = tz.ID
+		}
+
+		rule, x, err := entry.Expansion(tz, opts)
+		if err != nil {
+			opts.warnf("Skipping %s: %v", entry.UID, err)
+			continue
+		}
+		for occ := range rule.Occurrences(x, from, to) {
+			instances = append(instances, Instance{Entry: entry, TimeZone: tz, Occurrence: occ})
+		}
+	}
+
+	slices.SortStableFunc(instances, func(a, b Instance) int { return a.Start.Compare(b.Start) })
+	return instances, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func starts(seq func(func(vcstoics.Occurrence) bool)) []string {
+	var s []string
+	for occ := range seq {
+		s = append(s, occ.Start.Format(time.RFC3339))
+	}
+	return s
+}
+
+func TestOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	year := time.Date(2011, 6, 1, 0, 0, 0, 0, time.UTC)
+	tz := vcstoics.TimeZoneFromLocation(berlin, year, year)
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		x        vcstoics.Expansion
+		from, to string
+		want     []string
+	}{
+		{
+			"daylight saving time", "W1 #0",
+			vcstoics.Expansion{Start: date("2011-03-20T10:00:00Z"), TimeZone: tz},
+			"2011-03-01T00:00:00Z", "2011-04-01T00:00:00Z",
+			[]string{"2011-03-20T09:00:00Z", "2011-03-27T08:00:00Z"},
+		},
+		{
+			"month end skipped", "MD1 #0",
+			vcstoics.Expansion{Start: date("2011-01-31T10:00:00Z")},
+			"2011-01-01T00:00:00Z", "2011-06-01T00:00:00Z",
+			[]string{"2011-01-31T10:00:00Z", "2011-03-31T10:00:00Z", "2011-05-31T10:00:00Z"},
+		},
+		{
+			"month end clamped", "MD1 #0",
+			vcstoics.Expansion{Start: date("2011-01-31T10:00:00Z"), ClampMonthEnd: true},
+			"2011-01-01T00:00:00Z", "2011-05-01T00:00:00Z",
+			[]string{"2011-01-31T10:00:00Z", "2011-02-28T10:00:00Z", "2011-03-31T10:00:00Z", "2011-04-30T10:00:00Z"},
+		},
+		{
+			"leap day", "YM1 #0",
+			vcstoics.Expansion{Start: date("2012-02-29T10:00:00Z")},
+			"2012-01-01T00:00:00Z", "2017-01-01T00:00:00Z",
+			[]string{"2012-02-29T10:00:00Z", "2016-02-29T10:00:00Z"},
+		},
+		{
+			"count before the period", "D1 #5",
+			vcstoics.Expansion{Start: date("2011-06-01T10:00:00Z")},
+			"2011-06-04T00:00:00Z", "2011-07-01T00:00:00Z",
+			[]string{"2011-06-04T10:00:00Z", "2011-06-05T10:00:00Z"},
+		},
+		{
+			"until", "W1 TU TH 20110616T100000Z",
+			vcstoics.Expansion{Start: date("2011-06-07T10:00:00Z")},
+			"2011-06-01T00:00:00Z", "2011-07-01T00:00:00Z",
+			[]string{"2011-06-07T10:00:00Z", "2011-06-09T10:00:00Z", "2011-06-14T10:00:00Z", "2011-06-16T10:00:00Z"},
+		},
+		{
+			"exception dates", "D1 #4",
+			vcstoics.Expansion{
+				Start:   date("2011-06-01T10:00:00Z"),
+				ExDates: []time.Time{date("2011-06-02T10:00:00Z")},
+				RDates:  []time.Time{date("2011-06-10T12:00:00Z"), date("2011-06-03T10:00:00Z")},
+			},
+			"2011-06-01T00:00:00Z", "2011-07-01T00:00:00Z",
+			[]string{"2011-06-01T10:00:00Z", "2011-06-03T10:00:00Z", "2011-06-04T10:00:00Z", "2011-06-10T12:00:00Z"},
+		},
+		{
+			"overlapping the period", "D1 #3",
+			vcstoics.Expansion{Start: date("2011-06-01T22:00:00Z"), Duration: 4 * time.Hour},
+			"2011-06-02T00:00:00Z", "2011-06-03T00:00:00Z",
+			[]string{"2011-06-01T22:00:00Z", "2011-06-02T22:00:00Z"},
+		},
+		{
+			"last friday", "MP1 1- FR #0",
+			vcstoics.Expansion{Start: date("2011-06-24T10:00:00Z")},
+			"2011-06-01T00:00:00Z", "2011-09-01T00:00:00Z",
+			[]string{"2011-06-24T10:00:00Z", "2011-07-29T10:00:00Z", "2011-08-26T10:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := vcstoics.ParseRepeatRule(tt.rule, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := starts(rule.Occurrences(tt.x, date(tt.from), date(tt.to)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"TZ:+01\r\n" +
+		"DAYLIGHT:TRUE;+02;20110327T010000Z;20111030T010000Z;;\r\n" +
+		"BEGIN:VEVENT\r\nUID:daily\r\nSUMMARY:Daily\r\n" +
+		"DTSTART:20110605T140000Z\r\nDTEND:20110605T150000Z\r\n" +
+		"RRULE:D1 20110610T160000\r\nEXDATE:20110607T160000\r\n" +
+		"AALARM:20110605T134500Z;;;\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:once\r\nSUMMARY:Once\r\n" +
+		"DTSTART:20110608T090000\r\nDTEND:20110608T100000\r\n" +
+		"AALARM:20110608T064500Z;;;\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	from := time.Date(2011, 6, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2011, 6, 9, 0, 0, 0, 0, time.UTC)
+	instances, err := vcstoics.Expand(strings.NewReader(input), from, to, vcstoics.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, in := range instances {
+		got = append(got, in.Entry.UID+" "+in.Start.Format(time.RFC3339)+" "+in.End.Format(time.RFC3339))
+	}
+	want := []string{
+		"daily 2011-06-06T14:00:00Z 2011-06-06T15:00:00Z",
+		"once 2011-06-08T07:00:00Z 2011-06-08T08:00:00Z",
+		"daily 2011-06-08T14:00:00Z 2011-06-08T15:00:00Z",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	e := instances[2].Flatten()
+	if e.UID != "daily-20110608T140000Z" || e.DTStart != "20110608T140000Z" || e.DTEnd != "20110608T150000Z" ||
+		e.RRule != "" || e.ExDate != nil || len(e.Alarms) != 1 || e.Alarms[0].RunTime != "20110608T134500Z" {
+		t.Errorf("unexpected flattened entry %+v", e)
+	}
+
+	// A UTC alarm of a local start keeps its 15 minutes
+	e = instances[1].Flatten()
+	if e.DTStart != "20110608T070000Z" || len(e.Alarms) != 1 || e.Alarms[0].RunTime != "20110608T064500Z" {
+		t.Errorf("unexpected flattened entry %+v", e)
+	}
+}
+
+func TestExpandTodoDue(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nUID:start\r\nDTSTART:20110606T080000Z\r\nDUE:20110607T120000Z\r\nRRULE:W1 #3\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:due\r\nDUE:20110607T120000Z\r\nRRULE:W1 #3\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	from := time.Date(2011, 6, 12, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2011, 6, 19, 0, 0, 0, 0, time.UTC)
+	instances, err := vcstoics.Expand(strings.NewReader(input), from, to, vcstoics.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, in := range instances {
+		e := in.Flatten()
+		got = append(got, e.UID+" "+e.DTStart+" "+e.Due)
+	}
+	want := []string{
+		"start-20110613T080000Z 20110613T080000Z 20110614T120000Z",
+		"due-20110614T120000Z  20110614T120000Z",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest physical line allowed by RFC 5545, not
+// counting the line break
+const maxLineOctets = 75
+
+// foldLine splits a content line into physical lines of at most 75 octets,
+// each continuation starting with a space. Multi-byte UTF-8 sequences are
+// never split.
+func foldLine(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var sb strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		sb.WriteString(line[:i] + newLine + " ")
+		line = line[i:]
+
+		// The leading space counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	sb.WriteString(line)
+
+	return sb.String()
+}
+
+// foldLines folds every line of CRLF separated content
+func foldLines(s string) string {
+	lines := strings.Split(s, newLine)
+	for i, line := range lines {
+		lines[i] = foldLine(line)
+	}
+	return strings.Join(lines, newLine)
+}
+
+// unfold joins folded lines, so that UTF-8 sequences split by a careless
+// folder are whole again
+func unfold(data []byte) []byte {
+	for _, fold := range []string{"\r\n ", "\r\n\t", "\n ", "\n\t"} {
+		data = bytes.ReplaceAll(data, []byte(fold), nil)
+	}
+	return data
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestFolding(t *testing.T) {
+	summaries := []string{
+		strings.Repeat("a", 200),
+		strings.Repeat("ñ", 100),
+		"x" + strings.Repeat("€", 60),
+		strings.Repeat("🎉 ", 40),
+	}
+
+	for _, summary := range summaries {
+		input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:" + summary +
+			"\r\nDTSTART:20110617T060000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+		var out bytes.Buffer
+		if err := vcstoics.Convert(strings.NewReader(input), &out, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, line := range strings.Split(out.String(), "\r\n") {
+			if len(line) > 75 {
+				t.Errorf("line %d is %d octets long: %q", i+1, len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line %d splits a UTF-8 sequence: %q", i+1, line)
+			}
+		}
+
+		// Reading the output back must give the original text
+		cal, err := vcstoics.ParseVCalendar(&out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cal.Components[0].Value("SUMMARY"); got != summary {
+			t.Errorf("summary = %q, want %q", got, summary)
+		}
+	}
+}
+
+func TestUnfolding(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTST\r\n ART;TZID=Europe/Ber\r\n\tlin:20110617T\r\n 060000\r\n" +
+		"SUMMARY:Caf\xc3\r\n \xa9\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := vcstoics.ParseVCalendar(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event := cal.Components[0]
+	dtstart := event.Get("DTSTART")
+	if dtstart == nil || dtstart.Params.Get("TZID") != "Europe/Berlin" || dtstart.Value != "20110617T060000" {
+		t.Errorf("unexpected DTSTART %+v", dtstart)
+	}
+	if got := event.Value("SUMMARY"); got != "Café" {
+		t.Errorf("summary = %q", got)
+	}
+}
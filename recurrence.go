@@ -0,0 +1,268 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// exRuleHorizon limits the expansion of an unbounded EXRULE applied to an
+// unbounded RRULE
+const exRuleHorizon = 10 // years
+
+// dateValue is a date or date-time read from a vCalendar list
+type dateValue struct {
+	Time time.Time // wall clock time for local values and dates
+	Kind TimeKind
+}
+
+// parseDateList parses the semicolon separated dates and date-times of
+// EXDATE and RDATE values. Commas are accepted too.
+func parseDateList(s string) ([]dateValue, error) {
+	var values []dateValue
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if len(item) == 8 {
+			t, err := time.Parse("20060102", item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, dateValue{t, DateOnly})
+			continue
+		}
+
+		t, err := ParseDate(item)
+		if err != nil {
+			return nil, err
+		}
+		kind := UTCTime
+		if !strings.HasSuffix(item, "Z") {
+			kind = LocalTime
+		}
+		values = append(values, dateValue{t, kind})
+	}
+	return values, nil
+}
+
+// startType describes how the DTSTART of an entry is written. EXDATE and
+// RDATE values have to be written the same way.
+type startType struct {
+	start  time.Time // DTSTART as a wall clock time
+	allDay bool
+	utc    bool
+	tz     *TimeZone // time zone of local date-times, if known
+	tzid   string
+}
+
+// wall converts a value to the wall clock time DTSTART is expressed in.
+// Dates given for timed entries take the time of day of DTSTART.
+func (st startType) wall(v dateValue) time.Time {
+	t := v.Time
+	switch v.Kind {
+	case DateOnly:
+		if st.allDay {
+			return t
+		}
+
+		// The time of day of DTSTART, in the local time of the entry
+		start := st.start
+		if st.utc && st.tz != nil {
+			start = start.Add(st.tz.OffsetAt(start))
+		}
+		t = t.Add(start.Sub(dateOf(start)))
+		if st.utc && st.tz != nil {
+			t = st.tz.ToUTC(t)
+		}
+		return t
+	case UTCTime:
+		if !st.utc && st.tz != nil {
+			t = t.Add(st.tz.OffsetAt(t))
+		}
+	case LocalTime:
+		if st.utc && st.tz != nil {
+			t = st.tz.ToUTC(t)
+		}
+	}
+
+	if st.allDay {
+		return dateOf(t)
+	}
+	return t
+}
+
+// expansionZone is the time zone rules starting at DTSTART are expanded
+// in, nil when wall clock times need no conversion
+func (st startType) expansionZone() *TimeZone {
+	if st.utc || st.allDay {
+		return nil
+	}
+	return st.tz
+}
+
+// property formats a list of wall clock times as the named property
+func (st startType) property(name string, times []time.Time) string {
+	kind := LocalTime
+	params := ""
+	switch {
+	case st.allDay:
+		kind, params = DateOnly, ";VALUE=DATE"
+	case st.utc:
+		kind = UTCTime
+	case st.tzid != "":
+		params = ";TZID=" + st.tzid
+	}
+
+	values := make([]string, len(times))
+	for i, t := range times {
+		values[i] = FormatTime(t, kind)
+	}
+	return name + params + ":" + strings.Join(values, ",")
+}
+
+// parseDates parses EXDATE or RDATE values into wall clock times sorted
+// and without duplicates
+func (st startType) parseDates(lists []string) ([]time.Time, error) {
+	var times []time.Time
+	for _, list := range lists {
+		values, err := parseDateList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			times = append(times, st.wall(v))
+		}
+	}
+	return sortTimes(times), nil
+}
+
+// exRuleDates returns the occurrences of rrule and the rdates that the
+// exclusion rule removes. RFC 5545 deprecates EXRULE, so they are written
+// as EXDATE instead. It reports false when the result was cut short
+// because neither rule ends.
+func (st startType) exRuleDates(exrule, rrule *RepeatRule, rdates []time.Time) ([]time.Time, bool) {
+	tz := st.expansionZone()
+
+	var candidates iter.Seq[time.Time] = slices.Values(rdates)
+	if rrule != nil {
+		candidates = mergeTimes(rrule.expand(st.start, tz), slices.Values(rdates))
+	}
+
+	// Without an end to either rule only the first years are looked at
+	horizon := time.Time{}
+	if !exrule.bounded() && rrule != nil && !rrule.bounded() {
+		horizon = st.start.AddDate(exRuleHorizon, 0, 0)
+	}
+
+	// Unlike for RRULE, DTSTART is only excluded if it matches
+	next, stop := iter.Pull(exrule.occurrences(st.start, tz, false, false))
+	defer stop()
+
+	var excluded []time.Time
+	ex, ok := next()
+	for t := range candidates {
+		if !horizon.IsZero() && t.After(horizon) {
+			return excluded, false
+		}
+		for ok && ex.Before(t) {
+			ex, ok = next()
+		}
+		if !ok {
+			break
+		}
+		if ex.Equal(t) {
+			excluded = append(excluded, t)
+		}
+	}
+	return excluded, true
+}
+
+// bounded reports whether the rule has a limited number of occurrences
+func (r *RepeatRule) bounded() bool {
+	return r.Occurences > 0 || !r.Until.IsZero()
+}
+
+// mergeTimes merges two sorted sequences of times
+func mergeTimes(a, b iter.Seq[time.Time]) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		nextB, stop := iter.Pull(b)
+		defer stop()
+
+		tb, okB := nextB()
+		for ta := range a {
+			for okB && tb.Before(ta) {
+				if !yield(tb) {
+					return
+				}
+				tb, okB = nextB()
+			}
+			if !yield(ta) {
+				return
+			}
+		}
+		for okB {
+			if !yield(tb) {
+				return
+			}
+			tb, okB = nextB()
+		}
+	}
+}
+
+func sortTimes(times []time.Time) []time.Time {
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(times, time.Time.Equal)
+}
+
+// recurrenceDates returns the EXDATE and RDATE values of e as wall clock
+// times, including the dates excluded by its EXRULEs
+func (e *Entry) recurrenceDates(st startType, rrule *RepeatRule, warnf func(string, ...any)) (exdates, rdates []time.Time, err error) {
+	exdates, err = st.parseDates(e.ExDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid EXDATE: %w", err)
+	}
+	rdates, err = st.parseDates(e.RDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid RDATE: %w", err)
+	}
+
+	for _, rule := range e.ExRule {
+		exrule, err := ParseRepeatRule(rule, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid EXRULE: %w", err)
+		}
+		exrule.matchStart(e.DTStart, st.allDay, st.tz)
+
+		dates, complete := st.exRuleDates(exrule, rrule, rdates)
+		if !complete {
+			warnf("EXRULE %s of %s never ends, only excluding dates of the first %d years", rule, e.UID, exRuleHorizon)
+		}
+		exdates = sortTimes(append(exdates, dates...))
+	}
+
+	return exdates, rdates, nil
+}
+
+// recurrenceProperties writes the EXDATE and RDATE properties of an entry
+func (w *ICSWriter) recurrenceProperties(e *Entry, st startType, rrule *RepeatRule) error {
+	exdates, rdates, err := e.recurrenceDates(st, rrule, w.warnf)
+	if err != nil {
+		return err
+	}
+
+	if len(exdates) > 0 {
+		w.contents.WriteString(st.property("EXDATE", exdates) + newLine)
+	}
+	if len(rdates) > 0 {
+		w.contents.WriteString(st.property("RDATE", rdates) + newLine)
+	}
+	return nil
+}
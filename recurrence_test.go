@@ -0,0 +1,95 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestConvertRecurrenceDates(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		props   string
+		want    string // EXDATE and RDATE lines
+	}{
+		{
+			"utc exdate list", "",
+			"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:D1 #10\r\nEXDATE:20110609T100000Z;20110607T100000Z\r\n",
+			"EXDATE:20110607T100000Z,20110609T100000Z\n",
+		},
+		{
+			"local exdate for utc start", "TZ:+01\r\n",
+			"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:D1 #10\r\nEXDATE:20110607T110000\r\n",
+			"EXDATE:20110607T100000Z\n",
+		},
+		{
+			"utc exdate for zoned start", "TZ:+01\r\n",
+			"DTSTART:20110606T110000\r\nDTEND:20110606T120000\r\nRRULE:D1 #10\r\nEXDATE:20110607T100000Z\r\n",
+			"EXDATE;TZID=+01:20110607T110000\n",
+		},
+		{
+			"date exdate for timed entry", "TZ:+01\r\n",
+			"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:D1 #10\r\nEXDATE:20110608\r\n",
+			"EXDATE:20110608T100000Z\n",
+		},
+		{
+			"all-day", "",
+			"DTSTART:20110606T000000\r\nDTEND:20110606T000000\r\nRRULE:D1 #10\r\nEXDATE:20110607T000000;20110608\r\nRDATE:20110701T000000\r\n",
+			"EXDATE;VALUE=DATE:20110607,20110608\nRDATE;VALUE=DATE:20110701\n",
+		},
+		{
+			"floating rdate", "",
+			"DTSTART:20110606T100000\r\nDTEND:20110606T110000\r\nRDATE:20110701T100000,20110801T100000\r\n",
+			"RDATE:20110701T100000,20110801T100000\n",
+		},
+		{
+			"exrule", "",
+			"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:D1 #14\r\nEXRULE:W1 SA SU #0\r\nRDATE:20110625T100000Z\r\n",
+			"EXDATE:20110611T100000Z,20110612T100000Z,20110618T100000Z,20110619T100000Z,20110625T100000Z\nRDATE:20110625T100000Z\n",
+		},
+		{
+			"exrule with exdate", "",
+			"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:W1 MO #10\r\nEXRULE:MD1 1 2 3 4 5 6 7 #0\r\nEXDATE:20110620T100000Z\r\n",
+			"EXDATE:20110606T100000Z,20110620T100000Z,20110704T100000Z,20110801T100000Z\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\n" + tt.headers + "BEGIN:VEVENT\r\nUID:1\r\n" + tt.props +
+				"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			entries := convertString(t, input, vcstoics.Options{})
+			if got := propertyLines(entries, "EXDATE", "RDATE"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertUnboundedExRule(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\n" +
+		"DTSTART:20110606T100000Z\r\nDTEND:20110606T110000Z\r\nRRULE:D1 #0\r\nEXRULE:YM1 1 #0\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	var warnings int
+	entries := convertString(t, input, vcstoics.Options{
+		Warnf: func(string, ...any) { warnings++ },
+	})
+
+	// Only the 6th of January of the first ten years is excluded
+	var dates []string
+	for year := 2012; year <= 2021; year++ {
+		dates = append(dates, fmt.Sprintf("%d0106T100000Z", year))
+	}
+	want := "EXDATE:" + strings.Join(dates, ",") + "\n"
+	if got := propertyLines(entries, "EXDATE"); got != want || warnings != 1 {
+		t.Errorf("got %d warnings and %q, want one warning and %q", warnings, got, want)
+	}
+}
@@ -5,6 +5,7 @@ package vcstoics
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -32,21 +33,67 @@ func ParseFrequency(s string) (Frequency, error) {
 		return Daily, nil
 	case "WEEKLY", "W":
 		return Weekly, nil
-	case "MONTHLY", "MD":
+	case "MONTHLY", "MD", "MP":
 		return Monthly, nil
-	case "YEARLY", "YM":
+	case "YEARLY", "YM", "YD":
 		return Yearly, nil
 	default:
 		return 0, fmt.Errorf("unknown frequency: %s", s)
 	}
 }
 
-// RepeatRule represents a recurrence rule for calendar events
+// weekdayCodes are the two letter weekday names shared by vCalendar and
+// iCalendar, indexed by time.Weekday
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for i, code := range weekdayCodes {
+		if strings.EqualFold(s, code) {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// WeekdayNum is a weekday, optionally restricted to its n-th occurrence
+// within the month or year. Negative ordinals count from the end.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	if w.Ordinal == 0 {
+		return weekdayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.Ordinal) + weekdayCodes[w.Weekday]
+}
+
+// defaultOccurrences is the number of occurrences of a vCalendar rule
+// that has neither a count nor an end date
+const defaultOccurrences = 2
+
+// RepeatRule represents a recurrence rule for calendar events. The By
+// fields follow the BYxxx parts of RFC 5545, negative numbers count from
+// the end of the month or year.
 type RepeatRule struct {
 	Frequency  Frequency
 	Interval   int
 	Until      time.Time
+	UntilKind  TimeKind // how Until is written, UTC unless set
 	Occurences int
+
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	ByYearDay  []int
+	BySetPos   []int
+	ByHour     []int
+	ByMinute   []int
+
+	// IgnoredTimes are the times of a D rule that iCalendar cannot express
+	// as BYHOUR and BYMINUTE. The rule repeats at the time of DTSTART.
+	IgnoredTimes []string
 }
 
 // ToICS converts a RepeatRule to ICS format string
@@ -61,60 +108,119 @@ func (r *RepeatRule) ToICS() string {
 	if r.Occurences > 0 {
 		sb.WriteString(";COUNT=" + strconv.Itoa(r.Occurences))
 	} else if !r.Until.IsZero() {
-		sb.WriteString(";UNTIL=" + FormatDate(r.Until))
+		sb.WriteString(";UNTIL=" + FormatTime(r.Until, r.UntilKind))
 	}
 
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		sb.WriteString(";BYDAY=" + strings.Join(days, ","))
+	}
+	writeIntList(&sb, "BYMONTHDAY", r.ByMonthDay)
+	writeIntList(&sb, "BYYEARDAY", r.ByYearDay)
+	writeIntList(&sb, "BYMONTH", r.ByMonth)
+	writeIntList(&sb, "BYHOUR", r.ByHour)
+	writeIntList(&sb, "BYMINUTE", r.ByMinute)
+	writeIntList(&sb, "BYSETPOS", r.BySetPos)
+
 	return sb.String()
 }
 
-// ParseRepeatRule parses a VCS format repeat rule
+func writeIntList(sb *strings.Builder, name string, values []int) {
+	if len(values) == 0 {
+		return
+	}
+	sb.WriteString(";" + name + "=")
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Itoa(v))
+	}
+}
+
+// ParseRepeatRule parses a VCS format repeat rule such as "W1 MO WE #10",
+// "MP1 1+ 2- FR 20111231T000000" or "MD1 1 LD #0". It understands the
+// whole vCalendar 1.0 grammar:
+//
+//   - D<interval> [hhmm...]              daily, at the listed times
+//   - W<interval> [weekday...]           weekly, on the listed weekdays
+//   - MP<interval> [n+|n-... weekday...] monthly, on the n-th weekdays
+//   - MD<interval> [n|n-|nL|LD...]       monthly, on the listed days
+//   - YM<interval> [month...]            yearly, in the listed months
+//   - YD<interval> [day...]              yearly, on the listed days of the year
+//
+// A rule ends in #<count>, an end date or both. #0 repeats forever and,
+// as in vCalendar, a rule with neither repeats twice. iCalendar does not
+// allow both COUNT and UNTIL, so when both are given the end date wins:
+// it is the bound exporters compute and show to users. End dates are only
+// read if useEndDate is set, local ones are kept as wall clock times with
+// UntilKind set to LocalTime.
+//
+// The $ that some exporters append to list entries has no iCalendar
+// counterpart and is ignored. An MP position without weekdays refers to
+// the weekday of the first occurrence, it is kept in BySetPos and the
+// weekday has to be added to ByDay by the caller.
+//
+// iCalendar combines every BYHOUR with every BYMINUTE, so the times of a D
+// rule are only kept when they form such a grid, as 0800 0830 1200 1230
+// do. Other lists, such as 0800 1230, are left in IgnoredTimes.
 func ParseRepeatRule(rrule string, useEndDate bool) (*RepeatRule, error) {
-	parts := strings.Split(rrule, " ")
+	parts := strings.Fields(rrule)
 	if len(parts) == 0 {
 		return nil, fmt.Errorf("empty rule")
 	}
 
-	part0 := parts[0]
+	part0 := strings.ToUpper(parts[0])
+	modifiers := parts[1:]
 
-	// Check for end date or occurrences
-	var occurDate time.Time
-	occurCnt := 0
+	// The rule ends in a count, an end date or both, in either order
+	var (
+		occurDate  time.Time
+		occurLocal bool
+		hasEnd     bool
+	)
+	occurCnt := -1
 
-	occur := parts[len(parts)-1]
-	if strings.HasPrefix(occur, "#") {
-		var err error
-		occurCnt, err = strconv.Atoi(occur[1:])
-		if err != nil {
-			return nil, fmt.Errorf("could not parse occurrences: %w", err)
-		}
-	} else if strings.Contains(occur, "T") && useEndDate {
-		var err error
-		occurDate, err = ParseDate(occur)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse date: %w", err)
+	for len(modifiers) > 0 {
+		occur := modifiers[len(modifiers)-1]
+		if strings.HasPrefix(occur, "#") && occurCnt < 0 {
+			var err error
+			occurCnt, err = strconv.Atoi(occur[1:])
+			if err != nil || occurCnt < 0 {
+				return nil, fmt.Errorf("could not parse occurrences: %s", occur)
+			}
+		} else if len(occur) >= 15 && occur[8] == 'T' && !hasEnd {
+			var err error
+			occurDate, err = ParseDate(occur)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse date: %w", err)
+			}
+			occurLocal = !strings.HasSuffix(occur, "Z")
+			hasEnd = true
+		} else {
+			break
 		}
+		modifiers = modifiers[:len(modifiers)-1]
 	}
 
-	// Parse frequency type
-	var freq Frequency
-	var intervalStr string
-
-	switch {
-	case strings.HasPrefix(part0, "D"):
-		freq = Daily
-		intervalStr = part0[1:]
-	case strings.HasPrefix(part0, "W"):
-		freq = Weekly
-		intervalStr = part0[1:]
-	case strings.HasPrefix(part0, "MD"):
-		freq = Monthly
-		intervalStr = part0[2:]
-	case strings.HasPrefix(part0, "YM"):
-		freq = Yearly
-		intervalStr = part0[2:]
-	default:
+	// Parse frequency type, two letter types first
+	var (
+		kind        string
+		intervalStr string
+	)
+	for _, k := range []string{"MP", "MD", "YM", "YD", "D", "W"} {
+		if strings.HasPrefix(part0, k) {
+			kind, intervalStr = k, part0[len(k):]
+			break
+		}
+	}
+	if kind == "" {
 		return nil, fmt.Errorf("unknown frequency type in: %s", part0)
 	}
+	freq, _ := ParseFrequency(kind)
 
 	// Parse interval
 	interval, err := strconv.Atoi(intervalStr)
@@ -127,12 +233,167 @@ func ParseRepeatRule(rrule string, useEndDate bool) (*RepeatRule, error) {
 		Interval:  interval,
 	}
 
-	// Create appropriate rule type
-	if !occurDate.IsZero() {
+	if err := rr.parseModifiers(kind, modifiers); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case hasEnd && useEndDate:
 		rr.Until = occurDate
-	} else if occurCnt > 0 {
+		if occurLocal {
+			rr.UntilKind = LocalTime
+		}
+	case occurCnt > 0:
 		rr.Occurences = occurCnt
+	case occurCnt < 0 && !hasEnd:
+		rr.Occurences = defaultOccurrences
 	}
 
 	return &rr, nil
 }
+
+// parseModifiers fills the By fields from the list that follows the
+// frequency and interval of a rule
+func (r *RepeatRule) parseModifiers(kind string, modifiers []string) error {
+	// Positions of an MP rule apply to the weekdays that follow them
+	var (
+		positions    []int
+		afterWeekday bool
+		times        []string
+	)
+
+	for _, m := range modifiers {
+		m = strings.ToUpper(strings.TrimSuffix(m, "$"))
+
+		switch kind {
+		case "D":
+			if len(m) != 4 {
+				return fmt.Errorf("invalid time %q", m)
+			}
+			hour, errH := strconv.Atoi(m[:2])
+			minute, errM := strconv.Atoi(m[2:])
+			if errH != nil || errM != nil || hour > 23 || minute > 59 {
+				return fmt.Errorf("invalid time %q", m)
+			}
+			if !slices.Contains(times, m) {
+				times = append(times, m)
+			}
+			if !slices.Contains(r.ByHour, hour) {
+				r.ByHour = append(r.ByHour, hour)
+			}
+			if !slices.Contains(r.ByMinute, minute) {
+				r.ByMinute = append(r.ByMinute, minute)
+			}
+
+		case "W":
+			wd, ok := parseWeekday(m)
+			if !ok {
+				return fmt.Errorf("invalid weekday %q", m)
+			}
+			r.ByDay = append(r.ByDay, WeekdayNum{Weekday: wd})
+
+		case "MP":
+			if wd, ok := parseWeekday(m); ok {
+				afterWeekday = true
+				if len(positions) == 0 {
+					r.ByDay = append(r.ByDay, WeekdayNum{Weekday: wd})
+				}
+				for _, pos := range positions {
+					r.ByDay = append(r.ByDay, WeekdayNum{Ordinal: pos, Weekday: wd})
+				}
+				continue
+			}
+
+			pos, err := parseOrdinal(m, 5)
+			if err != nil {
+				return err
+			}
+			if afterWeekday {
+				positions, afterWeekday = nil, false
+			}
+			positions = append(positions, pos)
+
+		case "MD":
+			day, err := parseOrdinal(m, 31)
+			if err != nil {
+				return err
+			}
+			r.ByMonthDay = append(r.ByMonthDay, day)
+
+		case "YM":
+			month, err := strconv.Atoi(m)
+			if err != nil || month < 1 || month > 12 {
+				return fmt.Errorf("invalid month %q", m)
+			}
+			r.ByMonth = append(r.ByMonth, month)
+
+		case "YD":
+			day, err := parseOrdinal(m, 366)
+			if err != nil {
+				return err
+			}
+			r.ByYearDay = append(r.ByYearDay, day)
+		}
+	}
+
+	// Trailing positions without weekdays use the weekday of DTSTART
+	if len(positions) > 0 && !afterWeekday {
+		if len(r.ByDay) > 0 {
+			return fmt.Errorf("positions %v are not followed by a weekday", positions)
+		}
+		r.BySetPos = positions
+	}
+
+	if len(times) != len(r.ByHour)*len(r.ByMinute) {
+		r.ByHour, r.ByMinute, r.IgnoredTimes = nil, nil, times
+	}
+
+	return nil
+}
+
+// parseOrdinal parses list entries such as 3, 3+, 3-, 3L or LD, the last
+// two counting from the end
+func parseOrdinal(s string, max int) (int, error) {
+	if s == "LD" {
+		return -1, nil
+	}
+
+	digits, sign := s, 1
+	switch {
+	case strings.HasSuffix(s, "+"):
+		digits = s[:len(s)-1]
+	case strings.HasSuffix(s, "-"), strings.HasSuffix(s, "L"):
+		digits, sign = s[:len(s)-1], -1
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("invalid ordinal %q", s)
+	}
+	return sign * n, nil
+}
+
+// matchStart converts Until to the value type of DTSTART, as RFC 5545
+// requires: a date for all-day entries, UTC when DTSTART is in UTC or
+// refers to a time zone, and a floating time otherwise. Local end dates
+// are in tz, if known.
+func (r *RepeatRule) matchStart(dtStart string, allDay bool, tz *TimeZone) {
+	if r.Until.IsZero() {
+		return
+	}
+
+	switch {
+	case allDay:
+		if r.UntilKind == UTCTime && tz != nil {
+			r.Until = r.Until.Add(tz.OffsetAt(r.Until))
+		}
+		r.UntilKind = DateOnly
+	case strings.HasSuffix(dtStart, "Z") || tz != nil:
+		if r.UntilKind == LocalTime && tz != nil {
+			r.Until = tz.ToUTC(r.Until)
+		}
+		r.UntilKind = UTCTime
+	default:
+		r.UntilKind = LocalTime
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"slices"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestParseRepeatRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"D1 #5", "RRULE:FREQ=DAILY;INTERVAL=1;COUNT=5"},
+		{"D2 0800 1200 #0", "RRULE:FREQ=DAILY;INTERVAL=2;BYHOUR=8,12;BYMINUTE=0"},
+		{"D1 0800 0830 1200 1230 #0", "RRULE:FREQ=DAILY;INTERVAL=1;BYHOUR=8,12;BYMINUTE=0,30"},
+		{"D1 0800 1230 #4", "RRULE:FREQ=DAILY;INTERVAL=1;COUNT=4"},
+		{"W1 MO WE FR #10", "RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=10;BYDAY=MO,WE,FR"},
+		{"W2 TU$ TH #0", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH"},
+		{"MP1 1+ MO #0", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=1MO"},
+		{"MP6 1+ 2- FR #3", "RRULE:FREQ=MONTHLY;INTERVAL=6;COUNT=3;BYDAY=1FR,-2FR"},
+		{"MP1 2+ MO TU 1- FR #0", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=2MO,2TU,-1FR"},
+		{"MP1 1+ #0", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYSETPOS=1"},
+		{"MD1 1 15 #0", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=1,15"},
+		{"MD1 LD 2L 3- #0", "RRULE:FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=-1,-2,-3"},
+		{"YM1 6 #0", "RRULE:FREQ=YEARLY;INTERVAL=1;BYMONTH=6"},
+		{"YM1 1$ 7 #4", "RRULE:FREQ=YEARLY;INTERVAL=1;COUNT=4;BYMONTH=1,7"},
+		{"YD3 1 100 200 #10", "RRULE:FREQ=YEARLY;INTERVAL=3;COUNT=10;BYYEARDAY=1,100,200"},
+		{"D1 20110610T160000", "RRULE:FREQ=DAILY;INTERVAL=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rr, err := vcstoics.ParseRepeatRule(tt.rule, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rr.ToICS(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRepeatRuleIgnoredTimes(t *testing.T) {
+	rr, err := vcstoics.ParseRepeatRule("D1 0800 1230 #4", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"0800", "1230"}; !slices.Equal(rr.IgnoredTimes, want) {
+		t.Errorf("got ignored times %q, want %q", rr.IgnoredTimes, want)
+	}
+}
+
+func TestParseRepeatRuleErrors(t *testing.T) {
+	for _, rule := range []string{"", "X1", "Wx", "W1 XY", "MD1 32", "YM1 13", "MP1 6+ MO", "D1 2500", "MP1 MO 1+"} {
+		if _, err := vcstoics.ParseRepeatRule(rule, false); err == nil {
+			t.Errorf("expected an error for %q", rule)
+		}
+	}
+}
+
+func TestConvertRepeatRulePosition(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\n" +
+		"DTSTART:20110607T100000Z\r\nDTEND:20110607T110000Z\r\nRRULE:MP1 2+ #0\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	// 2011-06-07 is a Tuesday
+	got := propertyLines(convertString(t, input, vcstoics.Options{}), "RRULE")
+	if want := "RRULE:FREQ=MONTHLY;INTERVAL=1;BYDAY=TU;BYSETPOS=2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseRepeatRuleEnd(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"W1 MO", "RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=2;BYDAY=MO"},
+		{"W1 MO #0", "RRULE:FREQ=WEEKLY;INTERVAL=1;BYDAY=MO"},
+		{"D1 20111231T120000Z", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20111231T120000Z"},
+		{"D1 20111231T120000", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20111231T120000"},
+		{"D1 #5 20111231T120000Z", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20111231T120000Z"},
+		{"D1 20111231T120000Z #5", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20111231T120000Z"},
+		{"D1 #0 20111231T120000Z", "RRULE:FREQ=DAILY;INTERVAL=1;UNTIL=20111231T120000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rr, err := vcstoics.ParseRepeatRule(tt.rule, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := rr.ToICS(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertRepeatRuleUntil(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		start   string
+		end     string
+		rule    string
+		want    string // UNTIL of the RRULE
+	}{
+		{"utc start, local end", "TZ:+01\r\nDAYLIGHT:TRUE;+02;20110327T010000Z;20111030T010000Z;;\r\n",
+			"20110605T140000Z", "20110605T150000Z", "D1 20110610T160000", "UNTIL=20110610T140000Z"},
+		{"zoned start, utc end", "TZ:+01\r\n",
+			"20110605T140000", "20110605T150000", "D1 20110610T140000Z", "UNTIL=20110610T140000Z"},
+		{"zoned start, local end", "TZ:+01\r\n",
+			"20110605T140000", "20110605T150000", "D1 20110610T150000", "UNTIL=20110610T140000Z"},
+		{"floating start, local end", "",
+			"20110605T140000", "20110605T150000", "D1 20110610T160000", "UNTIL=20110610T160000"},
+		{"all-day, local end", "TZ:+01\r\n",
+			"20110605T000000", "20110605T000000", "D1 20110610T000000", "UNTIL=20110610"},
+		{"all-day, utc end", "TZ:+01\r\n",
+			"20110605T000000", "20110605T000000", "D1 20110609T230000Z", "UNTIL=20110610"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\n" + tt.headers + "BEGIN:VEVENT\r\nUID:1\r\n" +
+				"DTSTART:" + tt.start + "\r\nDTEND:" + tt.end + "\r\nRRULE:" + tt.rule + "\r\n" +
+				"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			got := propertyLines(convertString(t, input, vcstoics.Options{}), "RRULE")
+			if want := "RRULE:FREQ=DAILY;INTERVAL=1;" + tt.want + "\n"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"slices"
+	"strings"
+)
+
+// Agenda entry types of Symbian phones, given by X-EPOCAGENDAENTRYTYPE
+const (
+	SymbianAppointment = "APPOINTMENT" // timed entry
+	SymbianEvent       = "EVENT"       // day entry spanning one or more days
+	SymbianAnniversary = "ANNIVERSARY" // day entry repeating every year
+	SymbianTodo        = "TODO"
+	SymbianReminder    = "REMINDER" // note for a point in time or a day, not taking any
+)
+
+// applySymbian gives entries exported by Symbian phones the shape their
+// entry type stands for. Todos are filed under the category of their todo
+// list.
+func (e *Entry) applySymbian(comp *Component) {
+	if list := strings.TrimSpace(comp.Text("X-EPOCTODOLIST")); list != "" && !slices.Contains(e.Categories, list) {
+		e.Categories = append(e.Categories, list)
+	}
+
+	if !e.IsEvent || e.DTStart == "" {
+		return
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(comp.Value("X-EPOCAGENDAENTRYTYPE"))) {
+	case SymbianAnniversary:
+		e.AllDay = true
+		if e.RRule == "" {
+			e.RRule = "YM1 #0"
+		}
+	case SymbianEvent:
+		e.AllDay = true
+	case SymbianReminder:
+		// A point in time that does not block the calendar. Without an end
+		// of its own it ends where it starts, so one at midnight is taken
+		// for the whole day.
+		if e.DTEnd == "" {
+			e.DTEnd = e.DTStart
+		}
+		if e.Transp == "" {
+			e.Transp = "TRANSPARENT"
+		}
+	}
+}
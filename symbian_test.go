@@ -0,0 +1,111 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestConvertSymbianEntryTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		props string
+		want  string // RRULE, DTSTART, DTEND and TRANSP lines
+	}{
+		{
+			"anniversary",
+			"DTSTART:19800315T090000\r\nDTEND:19800315T090000\r\nX-EPOCAGENDAENTRYTYPE:ANNIVERSARY\r\n",
+			"RRULE:FREQ=YEARLY;INTERVAL=1\nDTSTART;VALUE=DATE:19800315\n",
+		},
+		{
+			"anniversary with rule",
+			"DTSTART:20110608T000000\r\nDTEND:20110608T000000\r\nX-EPOCAGENDAENTRYTYPE:ANNIVERSARY\r\nRRULE:YM1 6 #0\r\n",
+			"RRULE:FREQ=YEARLY;INTERVAL=1;BYMONTH=6\nDTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"multi-day event",
+			"DTSTART:20110608T000000\r\nDTEND:20110611T000000\r\nX-EPOCAGENDAENTRYTYPE:EVENT\r\n",
+			"DTSTART;VALUE=DATE:20110608\nDTEND;VALUE=DATE:20110611\n",
+		},
+		{
+			"event ending during the day",
+			"DTSTART:20110608T000000\r\nDTEND:20110609T120000\r\nX-EPOCAGENDAENTRYTYPE:EVENT\r\n",
+			"DTSTART;VALUE=DATE:20110608\nDTEND;VALUE=DATE:20110610\n",
+		},
+		{
+			"reminder",
+			"DTSTART:20110608T000000\r\nDTEND:20110609T000000\r\nX-EPOCAGENDAENTRYTYPE:REMINDER\r\n",
+			"TRANSP:TRANSPARENT\nDTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"reminder without an end",
+			"DTSTART:20110608T000000\r\nX-EPOCAGENDAENTRYTYPE:REMINDER\r\n",
+			"TRANSP:TRANSPARENT\nDTSTART;VALUE=DATE:20110608\n",
+		},
+		{
+			"timed reminder",
+			"DTSTART:20110608T093000\r\nDTEND:20110608T100000\r\nX-EPOCAGENDAENTRYTYPE:REMINDER\r\n",
+			"TRANSP:TRANSPARENT\nDTSTART:20110608T093000\nDTEND:20110608T100000\n",
+		},
+		{
+			"timed reminder without an end",
+			"DTSTART:20110608T093000\r\nX-EPOCAGENDAENTRYTYPE:REMINDER\r\n",
+			"TRANSP:TRANSPARENT\nDTSTART:20110608T093000\n",
+		},
+		{
+			"appointment",
+			"DTSTART:20110608T090000\r\nDTEND:20110608T100000\r\nX-EPOCAGENDAENTRYTYPE:APPOINTMENT\r\n",
+			"DTSTART:20110608T090000\nDTEND:20110608T100000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\n" + tt.props + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			entries := convertString(t, input, vcstoics.Options{})
+			if got := propertyLines(entries, "RRULE", "DTSTART", "DTEND", "TRANSP"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSymbianTodoList(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:1\r\nDUE:20110608T000000\r\n" +
+		"CATEGORIES:ERRAND\r\nX-EPOCTODOLIST:Shopping\r\nX-EPOCAGENDAENTRYTYPE:TODO\r\n" +
+		"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+	got := propertyLines(convertString(t, input, vcstoics.Options{}), "CATEGORIES")
+	if want := "CATEGORIES:ERRAND,Shopping\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpandSymbianEvent(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:trip\r\nSUMMARY:Trip\r\n" +
+		"DTSTART:20110608T000000\r\nDTEND:20110611T000000\r\nX-EPOCAGENDAENTRYTYPE:EVENT\r\nRRULE:YM1 #2\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	from := time.Date(2011, time.January, 1, 0, 0, 0, 0, time.UTC)
+	instances, err := vcstoics.Expand(strings.NewReader(input), from, from.AddDate(3, 0, 0), vcstoics.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("got %d occurrences, want 2", len(instances))
+	}
+
+	inst := instances[1]
+	if !inst.AllDay || inst.End.Sub(inst.Start) != 72*time.Hour {
+		t.Errorf("unexpected occurrence %+v", inst.Occurrence)
+	}
+	if e := inst.Flatten(); e.DTStart != "20120608T000000" || e.DTEnd != "20120611T000000" || !e.AllDay {
+		t.Errorf("unexpected flattened entry %+v", e)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import "strings"
+
+// UnescapeText turns a vCalendar TEXT value into plain text: \\, \; and \,
+// stand for the character itself and \n or \N for a line break. Other
+// backslashes are kept, as exporters are not consistent about escaping.
+// Line breaks, escaped or not, become "\n".
+func UnescapeText(s string) string {
+	s = normalizeNewlines(s)
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '\\', ';', ',':
+			sb.WriteByte(s[i+1])
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte('\\')
+			continue
+		}
+		i++
+	}
+	return sb.String()
+}
+
+// textEscaper escapes TEXT values as required by RFC 5545 section 3.3.11
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\n", `\n`,
+)
+
+// EscapeText escapes plain text for use as an iCalendar TEXT value
+func EscapeText(s string) string {
+	return textEscaper.Replace(normalizeNewlines(s))
+}
+
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// splitValue splits a structured value at the semicolons that are not
+// escaped, leaving escapes in the parts as they are
+func splitValue(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ';':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// escapeTextList escapes the values of a multi-valued TEXT property and
+// joins them with commas
+func escapeTextList(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = EscapeText(v)
+	}
+	return strings.Join(escaped, ",")
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`plain`, "plain"},
+		{`a\;b\,c\\d`, `a;b,c\d`},
+		{`one\ntwo\Nthree`, "one\ntwo\nthree"},
+		{"crlf\r\nand cr\r", "crlf\nand cr\n"},
+		{`C:\temp\`, `C:\temp\`},
+		{`\\n`, `\n`},
+	}
+
+	for _, tt := range tests {
+		if got := vcstoics.UnescapeText(tt.in); got != tt.want {
+			t.Errorf("UnescapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a;b,c\d`, `a\;b\,c\\d`},
+		{"one\ntwo\r\nthree", `one\ntwo\nthree`},
+	}
+
+	for _, tt := range tests {
+		if got := vcstoics.EscapeText(tt.in); got != tt.want {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := vcstoics.UnescapeText(vcstoics.EscapeText(tt.in)); got != strings.ReplaceAll(tt.in, "\r\n", "\n") {
+			t.Errorf("round trip of %q gave %q", tt.in, got)
+		}
+	}
+}
+
+func TestConvertText(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:1\r\n" +
+		"SUMMARY;ENCODING=QUOTED-PRINTABLE:Line=0D=0Abreak, semi=5C; back=5C=5Cslash\r\n" +
+		"DTSTART:20110617T060000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := vcstoics.ParseVCalendar(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := cal.Components[0].Text("SUMMARY"), "Line\nbreak, semi; back\\slash"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	got := propertyLines(convertString(t, input, vcstoics.Options{}), "SUMMARY")
+	if want := `SUMMARY:Line\nbreak\, semi\; back\\slash` + "\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DaylightRule is a single daylight saving period taken from a vCalendar
+// DAYLIGHT property
+type DaylightRule struct {
+	Offset       time.Duration // UTC offset while daylight saving time is in effect
+	Start        time.Time     // UTC instant daylight saving time begins
+	End          time.Time     // UTC instant daylight saving time ends
+	StandardName string
+	DaylightName string
+}
+
+// TimeZone describes the time zone of a vCalendar document, built from
+// its TZ and DAYLIGHT properties
+type TimeZone struct {
+	ID       string        // TZID used in the generated calendar
+	Offset   time.Duration // standard UTC offset
+	Daylight []DaylightRule
+
+	// Location is the IANA zone the time zone was resolved to, if any.
+	// It takes precedence over Offset and Daylight for time calculations.
+	Location *time.Location
+}
+
+// TimeZone returns the time zone declared by the calendar, or nil if it
+// has no TZ property
+func (c *Calendar) TimeZone() (*TimeZone, error) {
+	tzProp := c.Get("TZ")
+	if tzProp == nil {
+		return nil, nil
+	}
+
+	offset, err := parseUTCOffset(tzProp.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TZ: %w", err)
+	}
+
+	tz := &TimeZone{
+		ID:     strings.ReplaceAll(strings.TrimSpace(tzProp.Value), ":", ""),
+		Offset: offset,
+	}
+
+	for _, p := range c.GetAll("DAYLIGHT") {
+		rule, ok, err := parseDaylight(p.Value, offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DAYLIGHT: %w", err)
+		}
+		if ok {
+			tz.Daylight = append(tz.Daylight, rule)
+		}
+	}
+
+	sort.Slice(tz.Daylight, func(i, j int) bool {
+		return tz.Daylight[i].Start.Before(tz.Daylight[j].Start)
+	})
+
+	return tz, nil
+}
+
+// parseUTCOffset parses offsets such as +01, -0500, +05:30 or +1
+func parseUTCOffset(s string) (time.Duration, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ":", "")
+	if s == "" {
+		return 0, fmt.Errorf("empty offset")
+	}
+
+	sign := time.Duration(1)
+	switch s[0] {
+	case '-':
+		sign = -1
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	var hours, minutes int
+	var err error
+	switch len(s) {
+	case 1, 2:
+		hours, err = strconv.Atoi(s)
+	case 3, 4:
+		hours, err = strconv.Atoi(s[:len(s)-2])
+		if err == nil {
+			minutes, err = strconv.Atoi(s[len(s)-2:])
+		}
+	default:
+		err = fmt.Errorf("unexpected length")
+	}
+	if err != nil || hours > 14 || minutes > 59 {
+		return 0, fmt.Errorf("could not parse offset %q", s)
+	}
+
+	return sign * (time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute), nil
+}
+
+// parseDaylight parses "TRUE;+02;20110327T010000Z;20111030T010000Z;std;dst".
+// It reports false for DAYLIGHT:FALSE.
+func parseDaylight(value string, standard time.Duration) (DaylightRule, bool, error) {
+	parts := strings.Split(value, ";")
+	if !strings.EqualFold(strings.TrimSpace(parts[0]), "TRUE") {
+		return DaylightRule{}, false, nil
+	}
+	if len(parts) < 4 {
+		return DaylightRule{}, false, fmt.Errorf("expected offset, start and end in %q", value)
+	}
+
+	var (
+		rule DaylightRule
+		err  error
+	)
+
+	rule.Offset, err = parseUTCOffset(parts[1])
+	if err != nil {
+		return rule, false, err
+	}
+
+	// Transitions without a Z are local: the start in standard time, the
+	// end in daylight saving time
+	rule.Start, err = parseInstant(parts[2], standard)
+	if err != nil {
+		return rule, false, err
+	}
+	rule.End, err = parseInstant(parts[3], rule.Offset)
+	if err != nil {
+		return rule, false, err
+	}
+
+	if len(parts) > 4 {
+		rule.StandardName = strings.TrimSpace(parts[4])
+	}
+	if len(parts) > 5 {
+		rule.DaylightName = strings.TrimSpace(parts[5])
+	}
+
+	return rule, true, nil
+}
+
+// parseInstant parses a date-time into UTC, using offset for local times
+func parseInstant(s string, offset time.Duration) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	t, err := ParseDate(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !strings.HasSuffix(s, "Z") {
+		t = t.Add(-offset)
+	}
+	return t, nil
+}
+
+// OffsetAt returns the UTC offset in effect at the given instant
+func (tz *TimeZone) OffsetAt(t time.Time) time.Duration {
+	if tz.Location != nil {
+		_, offset := t.In(tz.Location).Zone()
+		return time.Duration(offset) * time.Second
+	}
+
+	for _, rule := range tz.Daylight {
+		if !t.Before(rule.Start) && t.Before(rule.End) {
+			return rule.Offset
+		}
+	}
+	return tz.Offset
+}
+
+// ToUTC converts a local wall clock time, given as a time in UTC with the
+// local fields, to the instant it denotes in this time zone
+func (tz *TimeZone) ToUTC(local time.Time) time.Time {
+	u := local.Add(-tz.Offset)
+	if off := tz.OffsetAt(u); off != tz.Offset {
+		// Daylight saving time, unless the wall clock falls in the gap
+		if d := local.Add(-off); tz.OffsetAt(d) == off {
+			return d
+		}
+	}
+	return u
+}
+
+// formatUTCOffset formats an offset as used by TZOFFSETFROM/TZOFFSETTO
+func formatUTCOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, int(d.Hours()), int(d.Minutes())%60)
+}
+
+// ToICS converts the time zone to a VTIMEZONE component, with a DAYLIGHT
+// and a STANDARD observance for every daylight saving period
+func (tz *TimeZone) ToICS() string {
+	std := formatUTCOffset(tz.Offset)
+
+	var sb strings.Builder
+	sb.WriteString("BEGIN:VTIMEZONE" + newLine)
+	sb.WriteString("TZID:" + tz.ID + newLine)
+
+	// Standard time applies to anything before the first daylight period
+	sb.WriteString("BEGIN:STANDARD" + newLine)
+	sb.WriteString("DTSTART:19700101T000000" + newLine)
+	sb.WriteString("TZOFFSETFROM:" + std + newLine)
+	sb.WriteString("TZOFFSETTO:" + std + newLine)
+	sb.WriteString("END:STANDARD" + newLine)
+
+	for _, rule := range tz.Daylight {
+		dst := formatUTCOffset(rule.Offset)
+
+		// Observance onsets are local times in the offset being left
+		sb.WriteString("BEGIN:DAYLIGHT" + newLine)
+		sb.WriteString("DTSTART:" + FormatLocalDate(rule.Start.Add(tz.Offset)) + newLine)
+		sb.WriteString("TZOFFSETFROM:" + std + newLine)
+		sb.WriteString("TZOFFSETTO:" + dst + newLine)
+		if rule.DaylightName != "" {
+			sb.WriteString("TZNAME:" + rule.DaylightName + newLine)
+		}
+		sb.WriteString("END:DAYLIGHT" + newLine)
+
+		sb.WriteString("BEGIN:STANDARD" + newLine)
+		sb.WriteString("DTSTART:" + FormatLocalDate(rule.End.Add(rule.Offset)) + newLine)
+		sb.WriteString("TZOFFSETFROM:" + dst + newLine)
+		sb.WriteString("TZOFFSETTO:" + std + newLine)
+		if rule.StandardName != "" {
+			sb.WriteString("TZNAME:" + rule.StandardName + newLine)
+		}
+		sb.WriteString("END:STANDARD" + newLine)
+	}
+
+	sb.WriteString("END:VTIMEZONE")
+	return sb.String()
+}
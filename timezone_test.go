@@ -0,0 +1,81 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+const timeZoneDoc = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:1.0\r\n" +
+	"TZ:+01\r\n" +
+	"DAYLIGHT:TRUE;+02;20110327T010000Z;20111030T010000Z;CET;CEST\r\n" +
+	"DAYLIGHT:TRUE;+02;20120325T010000Z;20121028T010000Z;CET;CEST\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:1\r\n" +
+	"DTSTART:20110607T100000\r\n" +
+	"DTEND:20110607T110000Z\r\n" +
+	"LAST-MODIFIED:20110601T130530Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestCalendarTimeZone(t *testing.T) {
+	cal, err := vcstoics.ParseVCalendar(strings.NewReader(timeZoneDoc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tz, err := cal.TimeZone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tz.ID != "+01" || tz.Offset != time.Hour || len(tz.Daylight) != 2 {
+		t.Fatalf("unexpected time zone: %+v", tz)
+	}
+
+	tests := []struct {
+		local time.Time
+		want  time.Time
+	}{
+		{time.Date(2011, 1, 10, 12, 0, 0, 0, time.UTC), time.Date(2011, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{time.Date(2011, 6, 7, 10, 0, 0, 0, time.UTC), time.Date(2011, 6, 7, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2012, 6, 7, 10, 0, 0, 0, time.UTC), time.Date(2012, 6, 7, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2012, 12, 1, 10, 0, 0, 0, time.UTC), time.Date(2012, 12, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tz.ToUTC(tt.local); !got.Equal(tt.want) {
+			t.Errorf("ToUTC(%v) = %v, want %v", tt.local, got, tt.want)
+		}
+	}
+}
+
+func TestConvertTimeZone(t *testing.T) {
+	var out bytes.Buffer
+	if err := vcstoics.Convert(strings.NewReader(timeZoneDoc), &out, "a@b.c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ics := out.String()
+
+	for _, want := range []string{
+		"BEGIN:VTIMEZONE\r\nTZID:+01\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20120325T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20121028T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\n",
+		"DTSTART;TZID=+01:20110607T100000\r\n",
+		"DTEND:20110607T110000Z\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("missing %q in:\n%s", want, ics)
+		}
+	}
+
+	if strings.Index(ics, "BEGIN:VTIMEZONE") > strings.Index(ics, "BEGIN:VEVENT") {
+		t.Errorf("VTIMEZONE should come before the events")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"slices"
+	"time"
+	_ "time/tzdata" // candidates must resolve on systems without zoneinfo
+)
+
+// preferredZones are tried first, so that the best known zone of a region
+// wins when several zones share the same rules
+var preferredZones = []string{
+	"Europe/Berlin", "Europe/London", "Europe/Athens", "Europe/Moscow",
+	"America/New_York", "America/Chicago", "America/Denver", "America/Phoenix",
+	"America/Los_Angeles", "America/Anchorage", "America/Halifax",
+	"America/Sao_Paulo", "America/Mexico_City", "Pacific/Honolulu",
+	"Asia/Tokyo", "Asia/Shanghai", "Asia/Kolkata", "Asia/Dubai",
+	"Asia/Singapore", "Australia/Sydney", "Australia/Perth",
+	"Pacific/Auckland", "Africa/Johannesburg", "Africa/Lagos", "Africa/Cairo",
+}
+
+// ResolveIANAZones returns the IANA zones whose offsets and daylight saving
+// transitions match tz, best match first. Without daylight saving periods
+// only zones that keep the same offset all through the year of ref match.
+func ResolveIANAZones(tz *TimeZone, ref time.Time) []string {
+	candidates := slices.Clone(preferredZones)
+	for _, name := range ianaZones {
+		if !slices.Contains(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	var matches []string
+	for _, name := range candidates {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			continue
+		}
+		if zoneMatches(tz, loc, ref) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+func zoneMatches(tz *TimeZone, loc *time.Location, ref time.Time) bool {
+	offsetAt := func(t time.Time) time.Duration {
+		_, offset := t.In(loc).Zone()
+		return time.Duration(offset) * time.Second
+	}
+
+	if len(tz.Daylight) == 0 {
+		year := ref.Year()
+		return offsetAt(time.Date(year, time.January, 15, 12, 0, 0, 0, time.UTC)) == tz.Offset &&
+			offsetAt(time.Date(year, time.July, 15, 12, 0, 0, 0, time.UTC)) == tz.Offset
+	}
+
+	for _, rule := range tz.Daylight {
+		if offsetAt(rule.Start.Add(-time.Second)) != tz.Offset ||
+			offsetAt(rule.Start) != rule.Offset ||
+			offsetAt(rule.End.Add(-time.Second)) != rule.Offset ||
+			offsetAt(rule.End) != tz.Offset {
+			return false
+		}
+	}
+	return true
+}
+
+// TimeZoneFromLocation describes an IANA zone as a TimeZone, listing its
+// daylight saving periods between the years of from and to
+func TimeZoneFromLocation(loc *time.Location, from, to time.Time) *TimeZone {
+	tz := &TimeZone{ID: loc.String(), Location: loc}
+
+	// Scan a little wider so periods spanning new year are complete
+	start := time.Date(from.Year()-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year()+2, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var (
+		rule   *DaylightRule
+		stdSet bool
+	)
+	for t := start; t.Before(end); t = nextTransition(loc, t, end) {
+		name, offset := t.In(loc).Zone()
+		isDST := t.In(loc).IsDST()
+
+		switch {
+		case isDST && rule == nil:
+			rule = &DaylightRule{
+				Offset:       time.Duration(offset) * time.Second,
+				Start:        t,
+				DaylightName: name,
+			}
+		case !isDST:
+			if !stdSet {
+				tz.Offset = time.Duration(offset) * time.Second
+				stdSet = true
+			}
+			if rule != nil {
+				rule.End = t
+				rule.StandardName = name
+				if rule.End.Year() >= from.Year() && rule.Start.Year() <= to.Year() {
+					tz.Daylight = append(tz.Daylight, *rule)
+				}
+				rule = nil
+			}
+		}
+	}
+
+	if !stdSet {
+		_, offset := start.In(loc).Zone()
+		tz.Offset = time.Duration(offset) * time.Second
+	}
+
+	return tz
+}
+
+// nextTransition returns the first instant after t, and before limit, at
+// which the offset of loc changes, or limit if there is none
+func nextTransition(loc *time.Location, t, limit time.Time) time.Time {
+	_, offset := t.In(loc).Zone()
+
+	// Find the day of the change, then narrow it down to the second
+	lo := t
+	hi := lo.Add(24 * time.Hour)
+	for {
+		if !hi.Before(limit) {
+			if _, o := limit.In(loc).Zone(); o == offset {
+				return limit
+			}
+			hi = limit
+			break
+		}
+		if _, o := hi.In(loc).Zone(); o != offset {
+			break
+		}
+		lo = hi
+		hi = hi.Add(24 * time.Hour)
+	}
+
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if _, o := mid.In(loc).Zone(); o == offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestResolveIANAZones(t *testing.T) {
+	cal, err := vcstoics.ParseVCalendar(strings.NewReader(timeZoneDoc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tz, err := cal.TimeZone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ref := time.Date(2011, 6, 7, 0, 0, 0, 0, time.UTC)
+	zones := vcstoics.ResolveIANAZones(tz, ref)
+	if len(zones) == 0 || zones[0] != "Europe/Berlin" {
+		t.Fatalf("expected Europe/Berlin first, got %v", zones)
+	}
+	if !slices.Contains(zones, "Europe/Paris") || slices.Contains(zones, "Europe/London") {
+		t.Errorf("unexpected matches %v", zones)
+	}
+
+	// Without daylight saving only zones with a fixed offset match
+	tz.Daylight = nil
+	zones = vcstoics.ResolveIANAZones(tz, ref)
+	if len(zones) == 0 || slices.Contains(zones, "Europe/Berlin") || !slices.Contains(zones, "Africa/Lagos") {
+		t.Errorf("unexpected matches %v", zones)
+	}
+}
+
+func TestTimeZoneFromLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	year := time.Date(2011, 6, 1, 0, 0, 0, 0, time.UTC)
+	tz := vcstoics.TimeZoneFromLocation(loc, year, year)
+
+	if tz.ID != "Europe/Berlin" || tz.Offset != time.Hour || len(tz.Daylight) != 1 {
+		t.Fatalf("unexpected time zone %+v", tz)
+	}
+
+	rule := tz.Daylight[0]
+	if !rule.Start.Equal(time.Date(2011, 3, 27, 1, 0, 0, 0, time.UTC)) ||
+		!rule.End.Equal(time.Date(2011, 10, 30, 1, 0, 0, 0, time.UTC)) ||
+		rule.Offset != 2*time.Hour || rule.DaylightName != "CEST" {
+		t.Errorf("unexpected daylight rule %+v", rule)
+	}
+}
+
+func TestConvertTimeZoneOption(t *testing.T) {
+	tests := []struct {
+		zone     string
+		tzid     string
+		warnings int
+	}{
+		{"", "+01", 0},
+		{"auto", "Europe/Berlin", 1},
+		{"America/New_York", "America/New_York", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			var out bytes.Buffer
+			var warnings int
+			err := vcstoics.ConvertWithOptions(strings.NewReader(timeZoneDoc), &out, vcstoics.Options{
+				TimeZone: tt.zone,
+				Warnf:    func(string, ...any) { warnings++ },
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ics := out.String()
+			if !strings.Contains(ics, "TZID:"+tt.tzid+"\r\n") || !strings.Contains(ics, "DTSTART;TZID="+tt.tzid+":20110607T100000\r\n") {
+				t.Errorf("expected time zone %s in:\n%s", tt.tzid, ics)
+			}
+			if warnings != tt.warnings {
+				t.Errorf("got %d warnings, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+
+	err := vcstoics.ConvertWithOptions(strings.NewReader(timeZoneDoc), &bytes.Buffer{}, vcstoics.Options{TimeZone: "Nowhere/Atlantis"})
+	if err == nil {
+		t.Errorf("expected an error for an unknown time zone")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownPolicy tells what happens to the properties of an entry that are
+// not converted, such as the X-EPOC properties of Symbian phones
+type UnknownPolicy int
+
+// UnknownPolicy constants
+const (
+	DropUnknown   UnknownPolicy = iota // leave them out
+	KeepUnknown                        // write them as they are
+	PrefixUnknown                      // write them with UnknownPrefix in front of their name
+)
+
+// UnknownPrefix is put in front of the names of unknown properties by
+// PrefixUnknown, so that the original names can be restored
+const UnknownPrefix = "X-VCS-"
+
+// ParseUnknownPolicy parses the name of a policy: drop, keep or prefix
+func ParseUnknownPolicy(s string) (UnknownPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "drop":
+		return DropUnknown, nil
+	case "keep":
+		return KeepUnknown, nil
+	case "prefix":
+		return PrefixUnknown, nil
+	}
+	return DropUnknown, fmt.Errorf("unknown property policy %q: expected drop, keep or prefix", s)
+}
+
+// knownProperties are the properties of entries that are converted, or
+// that the converter writes itself
+var knownProperties = map[string]bool{
+	"UID": true, "SUMMARY": true, "DESCRIPTION": true, "LOCATION": true,
+	"DTSTART": true, "DTEND": true, "DUE": true, "COMPLETED": true,
+	"RRULE": true, "EXRULE": true, "EXDATE": true, "RDATE": true,
+	"LAST-MODIFIED": true, "DTSTAMP": true, "SEQUENCE": true,
+	"STATUS": true, "PERCENT-COMPLETE": true, "PRIORITY": true, "CLASS": true, "TRANSP": true,
+	"CATEGORIES": true, "RESOURCES": true, "URL": true, "GEO": true, "RELATED-TO": true,
+	"ATTACH": true, "ATTENDEE": true, "ORGANIZER": true,
+	"AALARM": true, "DALARM": true, "MALARM": true, "PALARM": true,
+}
+
+// unknownProperties returns the properties of comp that are not converted
+func unknownProperties(comp *Component) []*Property {
+	var unknown []*Property
+	for _, p := range comp.Properties {
+		if !knownProperties[p.Name] {
+			unknown = append(unknown, p)
+		}
+	}
+	return unknown
+}
+
+// unknownToICS writes an unknown property under the given name. The value
+// is written decoded, so ENCODING and CHARSET no longer apply, except for
+// BASE64 data that is kept as binary. Line breaks are escaped.
+func unknownToICS(p *Property, name string) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+
+	binary := isBase64(p.Params) && !p.Params.Has("CHARSET")
+	for _, param := range p.Params {
+		if strings.EqualFold(param.Name, "ENCODING") || strings.EqualFold(param.Name, "CHARSET") {
+			continue
+		}
+		if binary && strings.EqualFold(param.Name, "VALUE") {
+			continue
+		}
+
+		values := make([]string, len(param.Values))
+		for i, v := range param.Values {
+			values[i] = quoteParam(v)
+		}
+		sb.WriteString(";" + strings.ToUpper(param.Name) + "=" + strings.Join(values, ","))
+	}
+
+	if binary {
+		sb.WriteString(";ENCODING=BASE64;VALUE=BINARY:" + strings.Join(strings.Fields(p.Raw), ""))
+		return sb.String()
+	}
+
+	value := strings.ReplaceAll(normalizeNewlines(p.Value), "\n", `\n`)
+	sb.WriteString(":" + value)
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestConvertUnknownProperties(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20110608T060000Z\r\n" +
+		"DTSTAMP:20110607T080000Z\r\n" +
+		"X-EPOCAGENDAENTRYTYPE:APPOINTMENT\r\nX-SYMBIAN-LUID:1257\r\n" +
+		"X-NOTE;ENCODING=QUOTED-PRINTABLE;CHARSET=ISO-8859-1:Gr=FC=DFe=0D=0Aaus K=F6ln\r\n" +
+		"X-ICON;TYPE=GIF;BASE64:R0lGODlh\r\nDCREATED;X-ORIGIN=\"a;b\":20110601T120000\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	converted := "BEGIN:VEVENT\nUID:1\nDTSTART:20110608T060000Z\nDTSTAMP:20110607T080000Z\n"
+	unknown := []string{
+		"X-EPOCAGENDAENTRYTYPE:APPOINTMENT\n",
+		"X-SYMBIAN-LUID:1257\n",
+		"X-NOTE:Grüße\\naus Köln\n",
+		"X-ICON;TYPE=GIF;ENCODING=BASE64;VALUE=BINARY:R0lGODlh\n",
+		"DCREATED;X-ORIGIN=\"a;b\":20110601T120000\n",
+	}
+
+	tests := []struct {
+		policy string
+		prefix string
+	}{
+		{"drop", ""},
+		{"keep", ""},
+		{"prefix", vcstoics.UnknownPrefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := vcstoics.ParseUnknownPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := converted
+			if policy != vcstoics.DropUnknown {
+				for _, line := range unknown {
+					want += tt.prefix + line
+				}
+			}
+			want += "END:VEVENT\n"
+
+			if got := convertString(t, input, vcstoics.Options{UnknownProperties: policy}); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+
+	if _, err := vcstoics.ParseUnknownPolicy("archive"); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
+
+func TestConvertPercentComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		todo string
+		want string
+	}{
+		{"in progress", "PERCENT-COMPLETE:40\r\n", "PERCENT-COMPLETE:40\n"},
+		{"completed", "PERCENT-COMPLETE:100\r\nCOMPLETED:20110608T060000Z\r\n", "PERCENT-COMPLETE:100\n"},
+		{"completed short of 100", "PERCENT-COMPLETE:60\r\nSTATUS:COMPLETED\r\n", "PERCENT-COMPLETE:100\n"},
+		{"completed without percentage", "STATUS:COMPLETED\r\n", "PERCENT-COMPLETE:100\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:1\r\n" + tt.todo + "END:VTODO\r\nEND:VCALENDAR\r\n"
+
+			entries := convertString(t, input, vcstoics.Options{UnknownProperties: vcstoics.KeepUnknown})
+			if got := propertyLines(entries, "PERCENT-COMPLETE", "X-VCS-PERCENT-COMPLETE"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultPriorityScale is the highest vCalendar priority assumed when none
+// is configured, as used by most phones: 1 high, 2 normal and 3 low
+const DefaultPriorityScale = 3
+
+// valueMap maps vCalendar values of a property to RFC 5545 values. Keys
+// are upper case.
+type valueMap map[string]string
+
+func (m valueMap) lookup(value string) (string, bool) {
+	mapped, ok := m[strings.ToUpper(strings.TrimSpace(value))]
+	return mapped, ok
+}
+
+// eventStatus maps the STATUS of a vCalendar event, which describes the
+// answer of the attendee, to the status of the event
+var eventStatus = valueMap{
+	"NEEDS ACTION": "TENTATIVE",
+	"NEEDS-ACTION": "TENTATIVE",
+	"SENT":         "TENTATIVE",
+	"TENTATIVE":    "TENTATIVE",
+	"ACCEPTED":     "CONFIRMED",
+	"CONFIRMED":    "CONFIRMED",
+	"CANCELLED":    "CANCELLED",
+}
+
+// todoStatus maps the STATUS of a vCalendar todo
+var todoStatus = valueMap{
+	"NEEDS ACTION": "NEEDS-ACTION",
+	"NEEDS-ACTION": "NEEDS-ACTION",
+	"SENT":         "NEEDS-ACTION",
+	"TENTATIVE":    "NEEDS-ACTION",
+	"ACCEPTED":     "IN-PROCESS",
+	"CONFIRMED":    "IN-PROCESS",
+	"IN-PROCESS":   "IN-PROCESS",
+	"COMPLETED":    "COMPLETED",
+	"CANCELLED":    "CANCELLED",
+}
+
+// eventPartStat maps the STATUS parameter of a vCalendar ATTENDEE to the
+// PARTSTAT of an event participant
+var eventPartStat = valueMap{
+	"NEEDS ACTION": "NEEDS-ACTION",
+	"NEEDS-ACTION": "NEEDS-ACTION",
+	"SENT":         "NEEDS-ACTION",
+	"ACCEPTED":     "ACCEPTED",
+	"CONFIRMED":    "ACCEPTED",
+	"DECLINED":     "DECLINED",
+	"TENTATIVE":    "TENTATIVE",
+	"DELEGATED":    "DELEGATED",
+}
+
+// todoPartStat maps the STATUS parameter of a vCalendar ATTENDEE to the
+// PARTSTAT of a todo participant
+var todoPartStat = valueMap{
+	"NEEDS ACTION": "NEEDS-ACTION",
+	"NEEDS-ACTION": "NEEDS-ACTION",
+	"SENT":         "NEEDS-ACTION",
+	"ACCEPTED":     "ACCEPTED",
+	"CONFIRMED":    "ACCEPTED",
+	"DECLINED":     "DECLINED",
+	"TENTATIVE":    "TENTATIVE",
+	"DELEGATED":    "DELEGATED",
+	"IN-PROCESS":   "IN-PROCESS",
+	"COMPLETED":    "COMPLETED",
+}
+
+// expectations maps the EXPECT parameter of a vCalendar ATTENDEE, how
+// much the attendance is expected, to the ROLE of the participant
+var expectations = valueMap{
+	"FYI":       "NON-PARTICIPANT",
+	"REQUEST":   "OPT-PARTICIPANT",
+	"REQUIRE":   "REQ-PARTICIPANT",
+	"IMMEDIATE": "REQ-PARTICIPANT",
+}
+
+var classes = valueMap{
+	"PUBLIC":       "PUBLIC",
+	"PRIVATE":      "PRIVATE",
+	"CONFIDENTIAL": "CONFIDENTIAL",
+}
+
+// transparencies maps TRANSP, a number in vCalendar where 0 blocks time
+var transparencies = valueMap{
+	"0":           "OPAQUE",
+	"1":           "TRANSPARENT",
+	"OPAQUE":      "OPAQUE",
+	"TRANSPARENT": "TRANSPARENT",
+}
+
+// MapStatus returns the RFC 5545 STATUS of a VEVENT or VTODO for a
+// vCalendar STATUS, or false if it has none
+func MapStatus(value string, isEvent bool) (string, bool) {
+	if isEvent {
+		return eventStatus.lookup(value)
+	}
+	return todoStatus.lookup(value)
+}
+
+// MapPartStat returns the RFC 5545 PARTSTAT of an attendee of a VEVENT or
+// VTODO for the vCalendar STATUS parameter, or false if it has none
+func MapPartStat(value string, isEvent bool) (string, bool) {
+	if isEvent {
+		return eventPartStat.lookup(value)
+	}
+	return todoPartStat.lookup(value)
+}
+
+// MapClass returns the RFC 5545 CLASS for a vCalendar CLASS, or false if
+// it has none
+func MapClass(value string) (string, bool) {
+	return classes.lookup(value)
+}
+
+// MapTransp returns the RFC 5545 TRANSP for a vCalendar TRANSP, or false
+// if it has none. Only events have a TRANSP in RFC 5545.
+func MapTransp(value string, isEvent bool) (string, bool) {
+	if !isEvent {
+		return "", false
+	}
+	return transparencies.lookup(value)
+}
+
+// MapPercentComplete returns the RFC 5545 PERCENT-COMPLETE for a vCalendar
+// PERCENT-COMPLETE, or false if it is not from 0 to 100. Only todos have a
+// PERCENT-COMPLETE in RFC 5545.
+func MapPercentComplete(value string, isEvent bool) (string, bool) {
+	p, err := strconv.Atoi(strings.TrimSpace(value))
+	if isEvent || err != nil || p < 0 || p > 100 {
+		return "", false
+	}
+	return strconv.Itoa(p), true
+}
+
+// MapPriority spreads a vCalendar PRIORITY from 1 to scale over the RFC
+// 5545 priorities 1 to 9, so that 1 stays the highest. 0 is undefined in
+// both. It reports false for values outside the scale.
+func MapPriority(value string, scale int) (string, bool) {
+	if scale <= 0 {
+		scale = DefaultPriorityScale
+	}
+
+	p, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || p < 0 || p > scale {
+		return "", false
+	}
+	if p == 0 || scale == 1 {
+		return strconv.Itoa(p), true
+	}
+	return strconv.Itoa(1 + (p-1)*8/(scale-1)), true
+}
@@ -0,0 +1,149 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestMapStatus(t *testing.T) {
+	tests := []struct {
+		value   string
+		isEvent bool
+		want    string
+		ok      bool
+	}{
+		{"NEEDS ACTION", true, "TENTATIVE", true},
+		{"SENT", true, "TENTATIVE", true},
+		{"accepted", true, "CONFIRMED", true},
+		{"CONFIRMED", true, "CONFIRMED", true},
+		{"DECLINED", true, "", false},
+		{"DELEGATED", true, "", false},
+		{"COMPLETED", true, "", false},
+		{"NEEDS ACTION", false, "NEEDS-ACTION", true},
+		{"SENT", false, "NEEDS-ACTION", true},
+		{"ACCEPTED", false, "IN-PROCESS", true},
+		{"COMPLETED", false, "COMPLETED", true},
+		{"DECLINED", false, "", false},
+		{"DELEGATED", false, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := vcstoics.MapStatus(tt.value, tt.isEvent)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MapStatus(%q, %v) = %q, %v, want %q, %v", tt.value, tt.isEvent, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMapPriority(t *testing.T) {
+	tests := []struct {
+		value string
+		scale int
+		want  string
+		ok    bool
+	}{
+		{"0", 0, "0", true},
+		{"1", 0, "1", true},
+		{"2", 0, "5", true},
+		{"3", 0, "9", true},
+		{"4", 0, "", false},
+		{"4", 9, "4", true},
+		{"9", 9, "9", true},
+		{"3", 5, "5", true},
+		{"high", 3, "", false},
+		{"-1", 3, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := vcstoics.MapPriority(tt.value, tt.scale)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MapPriority(%q, %d) = %q, %v, want %q, %v", tt.value, tt.scale, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMapPercentComplete(t *testing.T) {
+	tests := []struct {
+		value   string
+		isEvent bool
+		want    string
+		ok      bool
+	}{
+		{"0", false, "0", true},
+		{" 40", false, "40", true},
+		{"100", false, "100", true},
+		{"101", false, "", false},
+		{"-1", false, "", false},
+		{"half", false, "", false},
+		{"40", true, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := vcstoics.MapPercentComplete(tt.value, tt.isEvent)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MapPercentComplete(%q, %v) = %q, %v, want %q, %v", tt.value, tt.isEvent, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMapClassAndTransp(t *testing.T) {
+	for _, class := range []string{"PUBLIC", "PRIVATE", "CONFIDENTIAL"} {
+		if got, ok := vcstoics.MapClass(strings.ToLower(class)); got != class || !ok {
+			t.Errorf("MapClass(%q) = %q, %v", class, got, ok)
+		}
+	}
+	if _, ok := vcstoics.MapClass("SECRET"); ok {
+		t.Errorf("MapClass accepted SECRET")
+	}
+
+	tests := []struct {
+		value   string
+		isEvent bool
+		want    string
+		ok      bool
+	}{
+		{"0", true, "OPAQUE", true},
+		{"1", true, "TRANSPARENT", true},
+		{"TRANSPARENT", true, "TRANSPARENT", true},
+		{"2", true, "", false},
+		{"0", false, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := vcstoics.MapTransp(tt.value, tt.isEvent)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MapTransp(%q, %v) = %q, %v, want %q, %v", tt.value, tt.isEvent, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvertMappedValues(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20110608T060000Z\r\n" +
+		"STATUS:DELEGATED\r\nPRIORITY:1\r\nCLASS:CONFIDENTIAL\r\nTRANSP:1\r\n" +
+		"END:VEVENT\r\nBEGIN:VTODO\r\nUID:2\r\nSTATUS:NEEDS ACTION\r\nPRIORITY:5\r\nTRANSP:0\r\n" +
+		"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+	var warnings []string
+	entries := convertString(t, input, vcstoics.Options{
+		Warnf: func(format string, v ...any) { warnings = append(warnings, fmt.Sprintf(format, v...)) },
+	})
+
+	got := propertyLines(entries, "UID", "STATUS", "PRIORITY", "CLASS", "TRANSP",
+		"X-VCS-STATUS", "X-VCS-PRIORITY", "X-VCS-CLASS", "X-VCS-TRANSP")
+	want := "UID:1\nX-VCS-STATUS:DELEGATED\nPRIORITY:1\nCLASS:CONFIDENTIAL\nTRANSP:TRANSPARENT\n" +
+		"UID:2\nSTATUS:NEEDS-ACTION\nX-VCS-PRIORITY:5\nX-VCS-TRANSP:0\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(warnings) != 3 {
+		t.Fatalf("got %d warnings, want one per unmapped value", len(warnings))
+	}
+	if want := `PRIORITY "5" of 2 is outside the priority scale of 1 to 3`; !strings.HasPrefix(warnings[1], want) {
+		t.Errorf("got warning %q, want it to name the scale", warnings[1])
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoCalendar is returned by ParseVCalendar when the input does not
+// contain a BEGIN:VCALENDAR line
+var ErrNoCalendar = errors.New("missing BEGIN:VCALENDAR")
+
+// Param is a single property parameter, e.g. ENCODING=QUOTED-PRINTABLE
+type Param struct {
+	Name   string
+	Values []string
+	Bare   bool // vCalendar 1.0 style parameter given without a name
+}
+
+// Params holds the parameters of a property in the order they appeared
+type Params []Param
+
+// Get returns the first value of the named parameter, or "" if it is not set
+func (ps Params) Get(name string) string {
+	for _, p := range ps {
+		if strings.EqualFold(p.Name, name) && len(p.Values) > 0 {
+			return p.Values[0]
+		}
+	}
+	return ""
+}
+
+// Values returns all values of the named parameter, including repeated
+// occurrences of the parameter
+func (ps Params) Values(name string) []string {
+	var values []string
+	for _, p := range ps {
+		if strings.EqualFold(p.Name, name) {
+			values = append(values, p.Values...)
+		}
+	}
+	return values
+}
+
+// Has reports whether the named parameter is present
+func (ps Params) Has(name string) bool {
+	for _, p := range ps {
+		if strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Property is a single content line of a vCalendar document
+type Property struct {
+	Name   string // upper-cased property name
+	Params Params
+	Raw    string // value as found in the file, unfolded but not decoded
+	Value  string // value after applying the ENCODING and CHARSET parameters
+}
+
+// Text returns the value as plain text, with vCalendar escapes undone
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// Component is a BEGIN/END delimited block such as VEVENT or VTODO
+type Component struct {
+	Name       string // upper-cased component name
+	Properties []*Property
+	Components []*Component
+}
+
+// Get returns the first property with the given name, or nil
+func (c *Component) Get(name string) *Property {
+	for _, p := range c.Properties {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// GetAll returns every property with the given name
+func (c *Component) GetAll(name string) []*Property {
+	var props []*Property
+	for _, p := range c.Properties {
+		if strings.EqualFold(p.Name, name) {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Value returns the decoded value of the first property with the given
+// name, or "" if the component does not have it
+func (c *Component) Value(name string) string {
+	if p := c.Get(name); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// Text returns the first property with the given name as plain text, or
+// "" if the component does not have it
+func (c *Component) Text(name string) string {
+	if p := c.Get(name); p != nil {
+		return p.Text()
+	}
+	return ""
+}
+
+// Calendar is a parsed vCalendar 1.0 document. The calendar level
+// properties (VERSION, PRODID, TZ, DAYLIGHT, ...) are kept in Properties
+// and the entries in Components.
+type Calendar struct {
+	Component
+
+	// Encoding is the encoding of the input document, either detected
+	// or forced through Options.InputEncoding
+	Encoding string
+}
+
+// ParseVCalendar parses a single vCalendar document
+func ParseVCalendar(r io.Reader) (*Calendar, error) {
+	return ParseVCalendarWithOptions(r, Options{})
+}
+
+// ParseVCalendarWithOptions is like ParseVCalendar but honours the parsing
+// related fields of opts
+func ParseVCalendarWithOptions(r io.Reader, opts Options) (*Calendar, error) {
+	in, encoding, err := newInputReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		lines:          newLineReader(in),
+		opts:           opts,
+		transcoded:     encoding == EncodingUTF16LE || encoding == EncodingUTF16BE,
+		defaultCharset: opts.DefaultCharset,
+	}
+
+	// Values of a legacy 8-bit document most likely use the same charset
+	// as the document itself
+	if p.defaultCharset == "" {
+		p.defaultCharset = EncodingUTF8
+		if !p.transcoded {
+			p.defaultCharset = encoding
+		}
+	}
+	cal, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	cal.Encoding = encoding
+	return cal, nil
+}
+
+type parser struct {
+	lines          *lineReader
+	opts           Options
+	transcoded     bool   // input was converted to UTF-8 before parsing
+	defaultCharset string // charset of encoded values without CHARSET
+}
+
+// next returns the next content line with its value decoded
+func (p *parser) next() (*Property, error) {
+	prop, err := p.lines.next()
+	if err != nil {
+		return nil, err
+	}
+
+	// Text that was not encoded has already been converted to UTF-8 along
+	// with the rest of the input
+	if p.transcoded && !isEncoded(prop.Params) {
+		prop.Value = prop.Raw
+		return prop, nil
+	}
+
+	prop.Value = decodeValue(prop.Params, prop.Raw, p.defaultCharset)
+
+	return prop, nil
+}
+
+func (p *parser) parse() (*Calendar, error) {
+	cal := &Calendar{Component: Component{Name: "VCALENDAR"}}
+
+	// Everything up to BEGIN:VCALENDAR is ignored
+	for {
+		prop, err := p.next()
+		if err == io.EOF {
+			return nil, ErrNoCalendar
+		}
+		if err != nil {
+			return nil, err
+		}
+		if prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VCALENDAR") {
+			break
+		}
+	}
+
+	if err := p.parseComponent(&cal.Component); err != nil {
+		return nil, err
+	}
+
+	return cal, nil
+}
+
+// parseComponent reads properties and nested components into c until the
+// matching END line is found
+func (p *parser) parseComponent(c *Component) error {
+	for {
+		prop, err := p.next()
+		if err == io.EOF {
+			// Be lenient with truncated files
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			child := &Component{Name: strings.ToUpper(prop.Value)}
+			if err := p.parseComponent(child); err != nil {
+				return err
+			}
+			c.Components = append(c.Components, child)
+		case "END":
+			if !strings.EqualFold(prop.Value, c.Name) {
+				return fmt.Errorf("line %d: unexpected END:%s inside %s", p.lines.lineNo, prop.Value, c.Name)
+			}
+			return nil
+		default:
+			c.Properties = append(c.Properties, prop)
+		}
+	}
+}
+
+// lineReader splits the input into logical content lines, undoing both
+// whitespace folding and quoted-printable soft line breaks
+type lineReader struct {
+	reader  *bufio.Reader
+	lineNo  int
+	pending *string // physical line read ahead but not consumed yet
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReader(r)}
+}
+
+// physical returns the next physical line without its line terminator
+func (lr *lineReader) physical() (string, error) {
+	if lr.pending != nil {
+		line := *lr.pending
+		lr.pending = nil
+		return line, nil
+	}
+
+	line, err := lr.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	lr.lineNo++
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (lr *lineReader) unread(line string) {
+	lr.pending = &line
+}
+
+// next returns the next non-empty content line parsed into a Property
+func (lr *lineReader) next() (*Property, error) {
+	var prop *Property
+	for prop == nil {
+		line, err := lr.physical()
+		if err != nil {
+			return nil, err
+		}
+
+		// Blank and malformed lines are skipped, as most exporters are
+		// not too strict about what they write. A fold may split the name
+		// or the parameters though, so the line only parses once unfolded.
+		for {
+			prop, err = parseContentLine(line)
+			if err == nil {
+				break
+			}
+
+			cont, ok, err := lr.continuation()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			line += cont
+		}
+	}
+
+	qp := isQuotedPrintable(prop.Params)
+
+	for {
+		// Quoted-printable soft line break: the value continues verbatim
+		// on the next physical line
+		if qp && strings.HasSuffix(prop.Raw, "=") {
+			cont, err := lr.physical()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			prop.Raw = prop.Raw[:len(prop.Raw)-1] + cont
+			continue
+		}
+
+		cont, ok, err := lr.continuation()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		prop.Raw += cont
+	}
+
+	// BASE64 data may go on over lines that are not indented, up to a
+	// blank line
+	if isBase64(prop.Params) {
+		for {
+			line, err := lr.physical()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(line) == "" {
+				break
+			}
+			if !isBase64Line(line) {
+				lr.unread(line)
+				break
+			}
+			prop.Raw += line
+		}
+	}
+
+	return prop, nil
+}
+
+// continuation returns the next physical line without its leading
+// whitespace if it continues a folded line. Otherwise the line is left for
+// the next call and false is returned.
+func (lr *lineReader) continuation() (string, bool, error) {
+	cont, err := lr.physical()
+	if err == io.EOF {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if cont != "" && (cont[0] == ' ' || cont[0] == '\t') {
+		return cont[1:], true, nil
+	}
+
+	lr.unread(cont)
+	return "", false, nil
+}
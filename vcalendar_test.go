@@ -0,0 +1,152 @@
+// Copyright (c) Diogo Correia
+// SPDX-License-Identifier: MIT
+
+package vcstoics_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	vcstoics "github.com/dvcorreia/vcs-to-ics"
+)
+
+func TestParseVCalendar(t *testing.T) {
+	const input = "BEGIN:VCALENDAR\r\n" +
+		"VERSION:1.0\r\n" +
+		"TZ:+01\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:folded\r\n" +
+		"  line\r\n" +
+		"DESCRIPTION;ENCODING=QUOTED-PRINTABLE:soft=\r\n" +
+		"break=20here\r\n" +
+		"X-EPOCAGENDAENTRYTYPE:APPOINTMENT\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"TRIGGER:-PT15M\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := vcstoics.ParseVCalendar(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := cal.Value("TZ"); got != "+01" {
+		t.Errorf("TZ = %q, want %q", got, "+01")
+	}
+
+	if len(cal.Components) != 1 || cal.Components[0].Name != "VEVENT" {
+		t.Fatalf("expected a single VEVENT, got %+v", cal.Components)
+	}
+	event := cal.Components[0]
+
+	tests := []struct {
+		name, raw, value string
+	}{
+		{"SUMMARY", "folded line", "folded line"},
+		{"DESCRIPTION", "softbreak=20here", "softbreak here"},
+		{"X-EPOCAGENDAENTRYTYPE", "APPOINTMENT", "APPOINTMENT"},
+	}
+	for _, tt := range tests {
+		prop := event.Get(tt.name)
+		if prop == nil {
+			t.Errorf("missing property %s", tt.name)
+			continue
+		}
+		if prop.Raw != tt.raw || prop.Value != tt.value {
+			t.Errorf("%s = (%q, %q), want (%q, %q)", tt.name, prop.Raw, prop.Value, tt.raw, tt.value)
+		}
+	}
+
+	if got := event.Get("DESCRIPTION").Params.Get("encoding"); got != "QUOTED-PRINTABLE" {
+		t.Errorf("ENCODING parameter = %q", got)
+	}
+
+	if len(event.Components) != 1 || event.Components[0].Value("TRIGGER") != "-PT15M" {
+		t.Errorf("expected nested VALARM, got %+v", event.Components)
+	}
+}
+
+func TestParseVCalendarErrors(t *testing.T) {
+	_, err := vcstoics.ParseVCalendar(strings.NewReader("SUMMARY:nothing here\r\n"))
+	if !errors.Is(err, vcstoics.ErrNoCalendar) {
+		t.Errorf("expected ErrNoCalendar, got %v", err)
+	}
+
+	_, err = vcstoics.ParseVCalendar(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VTODO\r\n"))
+	if err == nil {
+		t.Errorf("expected an error for mismatched END")
+	}
+}
+
+func TestParseVCalendarParams(t *testing.T) {
+	tests := []struct {
+		line   string
+		name   string
+		params vcstoics.Params
+		value  string
+	}{
+		{
+			line: "DESCRIPTION;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:a=3Db",
+			name: "DESCRIPTION",
+			params: vcstoics.Params{
+				{Name: "CHARSET", Values: []string{"UTF-8"}},
+				{Name: "ENCODING", Values: []string{"QUOTED-PRINTABLE"}},
+			},
+			value: "a=b",
+		},
+		{
+			line:   "SUMMARY;QUOTED-PRINTABLE:caf=C3=A9",
+			name:   "SUMMARY",
+			params: vcstoics.Params{{Name: "ENCODING", Values: []string{"QUOTED-PRINTABLE"}, Bare: true}},
+			value:  "café",
+		},
+		{
+			line:   "LOCATION;LANGUAGE=de:Berlin",
+			name:   "LOCATION",
+			params: vcstoics.Params{{Name: "LANGUAGE", Values: []string{"de"}}},
+			value:  "Berlin",
+		},
+		{
+			line:   "AALARM;TYPE=X-EPOCSOUND:20110605T134500Z;;;",
+			name:   "AALARM",
+			params: vcstoics.Params{{Name: "TYPE", Values: []string{"X-EPOCSOUND"}}},
+			value:  "20110605T134500Z;;;",
+		},
+		{
+			line:   "AALARM;WAVE;BASE64:aGk=",
+			name:   "AALARM",
+			params: vcstoics.Params{{Name: "TYPE", Values: []string{"WAVE"}, Bare: true}, {Name: "ENCODING", Values: []string{"BASE64"}, Bare: true}},
+			value:  "hi",
+		},
+		{
+			line:   `ATTENDEE;X-NOTE="a;b:c",d;ROLE=OWNER:John`,
+			name:   "ATTENDEE",
+			params: vcstoics.Params{{Name: "X-NOTE", Values: []string{"a;b:c", "d"}}, {Name: "ROLE", Values: []string{"OWNER"}}},
+			value:  "John",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + tt.line + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+			cal, err := vcstoics.ParseVCalendar(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			prop := cal.Components[0].Get(tt.name)
+			if prop == nil {
+				t.Fatalf("missing property %s", tt.name)
+			}
+			if !reflect.DeepEqual(prop.Params, tt.params) {
+				t.Errorf("params = %+v, want %+v", prop.Params, tt.params)
+			}
+			if prop.Value != tt.value {
+				t.Errorf("value = %q, want %q", prop.Value, tt.value)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package vcstoics
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"time"
 )
@@ -17,19 +18,37 @@ const (
 // ICSWriter handles writing calendar data in ICS format
 type ICSWriter struct {
 	Email         string
+	Now           func() time.Time              // clock used for DTSTAMP, defaults to time.Now
+	Warnf         func(format string, v ...any) // defaults to printing to standard error
+	PriorityScale int                           // highest vCalendar priority, see MapPriority
+	AttachmentDir string                        // directory inline attachments are extracted to, if set
+	Unknown       UnknownPolicy                 // what to do with properties that are not converted
+	AllDay        AllDayPolicy                  // which entries with UTC times are all-day, see AllDayPolicy
 	writer        io.Writer
 	contents      strings.Builder
 	headerWritten bool
 	closed        bool
+	zones         map[string]*TimeZone // time zones added so far, by ID
 }
 
 func NewICSWriter(email string, writer io.Writer) *ICSWriter {
 	return &ICSWriter{
 		Email:  email,
+		Now:    time.Now,
 		writer: writer,
 	}
 }
 
+func (w *ICSWriter) warnf(format string, v ...any) {
+	if w.Warnf != nil {
+		w.Warnf(format, v...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
+
+// Write writes raw content after the calendar header. Unlike entries and
+// time zones, it is not folded.
 func (w *ICSWriter) Write(p []byte) (n int, err error) {
 	if w.closed {
 		return 0, fmt.Errorf("writer is closed")
@@ -54,7 +73,7 @@ func (w *ICSWriter) writeHeader() error {
 	}
 	header += "VERSION:2.0" + newLine
 
-	_, err := w.writer.Write([]byte(header))
+	_, err := w.writer.Write([]byte(foldLines(header)))
 	if err != nil {
 		return err
 	}
@@ -62,7 +81,58 @@ func (w *ICSWriter) writeHeader() error {
 	w.headerWritten = true
 	return nil
 }
+
+// AddTimeZone writes a VTIMEZONE that entries can refer to by its ID
+func (w *ICSWriter) AddTimeZone(tz *TimeZone) error {
+	if w.closed {
+		return fmt.Errorf("writer is closed")
+	}
+
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	if w.zones == nil {
+		w.zones = make(map[string]*TimeZone)
+	}
+	w.zones[tz.ID] = tz
+
+	_, err := w.writer.Write([]byte(foldLines(tz.ToICS() + newLine)))
+	return err
+}
+
+// AddEvent writes an entry given its individual fields. Entries written
+// this way have no UID, use AddEntry for full control.
 func (w *ICSWriter) AddEvent(isEvent bool, summary, description, location, dtStart, dtEnd, rrule, dtStamp, sequence, due, status, alarm string) error {
+	return w.AddEntry(&Entry{
+		IsEvent:     isEvent,
+		Summary:     summary,
+		Description: description,
+		Location:    location,
+		DTStart:     dtStart,
+		DTEnd:       dtEnd,
+		RRule:       rrule,
+		DTStamp:     dtStamp,
+		Sequence:    sequence,
+		Due:         due,
+		Status:      status,
+		Alarms:      displayAlarms(alarm),
+	})
+}
+
+// displayAlarms returns a display alarm going off at runTime, or none if
+// runTime is empty
+func displayAlarms(runTime string) []*Alarm {
+	if runTime == "" {
+		return nil
+	}
+	return []*Alarm{{Action: ActionDisplay, RunTime: runTime}}
+}
+
+// AddEntry writes a VEVENT or VTODO
+func (w *ICSWriter) AddEntry(e *Entry) error {
 	if w.closed {
 		return fmt.Errorf("writer is closed")
 	}
@@ -77,113 +147,333 @@ func (w *ICSWriter) AddEvent(isEvent bool, summary, description, location, dtSta
 	// Build event content
 	w.contents.Reset() // Clear the buffer for this event
 
-	if isEvent {
+	if e.IsEvent {
+		if e.DTStart == "" {
+			return fmt.Errorf("no start date specified")
+		}
+		tz := w.zones[e.TZID]
+		day, allDay := e.allDaySpan(tz, w.AllDay)
+
 		w.contents.WriteString("BEGIN:VEVENT" + newLine)
-		if w.Email != "" {
-			w.contents.WriteString("ORGANIZER:" + w.Email + newLine)
+		if e.UID != "" {
+			w.contents.WriteString("UID:" + e.UID + newLine)
 		}
+		w.writeParticipants(e)
 
-		if summary != "" {
-			w.contents.WriteString("SUMMARY:" + summary + newLine)
+		if e.Summary != "" {
+			w.contents.WriteString("SUMMARY:" + EscapeText(e.Summary) + newLine)
 		}
 
-		if description != "" {
-			w.contents.WriteString("DESCRIPTION:" + description + newLine)
+		if e.Description != "" {
+			w.contents.WriteString("DESCRIPTION:" + EscapeText(e.Description) + newLine)
 		}
 
-		if location != "" {
-			w.contents.WriteString("LOCATION:" + location + newLine)
+		if e.Location != "" {
+			w.contents.WriteString("LOCATION:" + EscapeText(e.Location) + newLine)
 		}
 
-		if rrule != "" {
-			repeatRule, err := ParseRepeatRule(rrule, false)
-			if err == nil && repeatRule != nil {
-				w.contents.WriteString(repeatRule.ToICS() + newLine)
-			}
+		w.writeMappedProperties(e)
+		w.writeClassification(e)
+		if err := w.writeAttachments(e); err != nil {
+			return err
 		}
 
-		if dtStart != "" {
-			w.contents.WriteString("DTSTART")
-			if checkForSameStartAndEndTime(dtStart, dtEnd) {
-				if checkForStartTimeIsZero(dtStart) {
-					start, err := ParseDate(dtStart)
-					if err == nil {
-						w.contents.WriteString(";VALUE=DATE:" + FormatTimeForDayEvent(start) + newLine)
-					} else {
-						// Fallback if parsing fails
-						w.contents.WriteString(":" + dtStart + newLine)
-					}
-				} else {
-					w.contents.WriteString(":" + dtStart + newLine)
-				}
-			} else {
-				w.contents.WriteString(":" + dtStart + newLine)
-				if dtEnd != "" {
-					w.contents.WriteString("DTEND:" + dtEnd + newLine)
-				}
-			}
-		} else {
-			return fmt.Errorf("no start date specified")
+		st := e.startType(nil, tz)
+		if allDay {
+			st = e.startType(&day, tz)
 		}
 
-		if dtStamp != "" {
-			w.contents.WriteString("DTSTAMP:" + dtStamp + newLine)
-		} else {
-			// Get current UTC time if no dtstamp provided
-			w.contents.WriteString("DTSTAMP:" + FormatDate(time.Now()) + newLine)
+		repeatRule := e.repeatRule(st, w.warnf)
+		if repeatRule != nil {
+			w.contents.WriteString(repeatRule.ToICS() + newLine)
 		}
 
-		if alarm != "" {
-			start, errStart := ParseDate(dtStart)
-			alarmTime, errAlarm := ParseDate(alarm)
+		if err := w.recurrenceProperties(e, st, repeatRule); err != nil {
+			w.warnf("Ignoring recurrence dates of %s: %v", e.UID, err)
+		}
 
-			if errStart == nil && errAlarm == nil {
-				alarmObj := NewAlarm(start, alarmTime)
-				w.contents.WriteString(alarmObj.ToICS(summary) + newLine)
+		w.contents.WriteString("DTSTART")
+		if allDay {
+			w.contents.WriteString(";VALUE=DATE:" + FormatTimeForDayEvent(day.start) + newLine)
+			if day.days > 1 {
+				w.contents.WriteString("DTEND;VALUE=DATE:" + FormatTimeForDayEvent(day.end()) + newLine)
+			}
+		} else {
+			w.contents.WriteString(dateTimeValue(e.DTStart, e.TZID) + newLine)
+			if e.DTEnd != "" && e.DTEnd != e.DTStart {
+				w.contents.WriteString("DTEND" + dateTimeValue(e.DTEnd, e.TZID) + newLine)
 			}
 		}
 
+		w.writeDTStamp(e)
+
+		w.writeUnknown(e)
+		w.writeAlarms(e, &st, "")
+
 		w.contents.WriteString("END:VEVENT" + newLine)
 	} else {
 		// Handle VTODO
 		w.contents.WriteString("BEGIN:VTODO" + newLine)
-
-		if dtStamp != "" {
-			w.contents.WriteString("DTSTAMP:" + dtStamp + newLine)
-		} else {
-			w.contents.WriteString("DTSTAMP:" + FormatDate(time.Now()) + newLine)
+		if e.UID != "" {
+			w.contents.WriteString("UID:" + e.UID + newLine)
 		}
 
-		if sequence != "" {
-			w.contents.WriteString("SEQUENCE:" + sequence + newLine)
+		w.writeDTStamp(e)
+
+		if e.Sequence != "" {
+			w.contents.WriteString("SEQUENCE:" + e.Sequence + newLine)
 		} else {
 			w.contents.WriteString("SEQUENCE:0" + newLine)
 		}
 
-		if w.Email != "" {
-			w.contents.WriteString("ORGANIZER:" + w.Email + newLine)
+		w.writeParticipants(e)
+
+		// Recurrence needs a DTSTART, DUE takes its place if there is none
+		todo := e
+		if e.DTStart == "" && e.Due != "" && e.recurs() {
+			withStart := *e
+			withStart.DTStart = e.Due
+			todo = &withStart
 		}
 
-		if due != "" {
-			w.contents.WriteString("DUE:" + due + newLine)
+		if todo.DTStart != "" {
+			w.contents.WriteString("DTSTART" + dateTimeValue(todo.DTStart, e.TZID) + newLine)
 		}
 
-		if status != "" {
-			w.contents.WriteString("STATUS:" + status + newLine)
+		if e.Due != "" {
+			w.contents.WriteString("DUE" + dateTimeValue(e.Due, e.TZID) + newLine)
 		}
 
-		if summary != "" {
-			w.contents.WriteString("SUMMARY:" + summary + newLine)
+		if e.Completed != "" {
+			if completed, err := utcValue(e.Completed, w.zones[e.TZID]); err == nil {
+				w.contents.WriteString("COMPLETED:" + completed + newLine)
+			} else {
+				w.warnf("Ignoring completion date of %s: %v", e.UID, err)
+			}
+		}
+
+		w.writeMappedProperties(e)
+
+		if e.Summary != "" {
+			w.contents.WriteString("SUMMARY:" + EscapeText(e.Summary) + newLine)
+		}
+
+		if e.Description != "" {
+			w.contents.WriteString("DESCRIPTION:" + EscapeText(e.Description) + newLine)
+		}
+
+		if e.Location != "" {
+			w.contents.WriteString("LOCATION:" + EscapeText(e.Location) + newLine)
+		}
+
+		w.writeClassification(e)
+		if err := w.writeAttachments(e); err != nil {
+			return err
 		}
 
+		// Todo alarms go off relative to DTSTART, else DUE, or at a fixed
+		// time without either
+		var anchor *startType
+		related := ""
+		switch {
+		case todo.DTStart != "":
+			st := todo.startType(nil, w.zones[e.TZID])
+			anchor = &st
+
+			repeatRule := todo.repeatRule(st, w.warnf)
+			if repeatRule != nil {
+				w.contents.WriteString(repeatRule.ToICS() + newLine)
+			}
+			if err := w.recurrenceProperties(todo, st, repeatRule); err != nil {
+				w.warnf("Ignoring recurrence dates of %s: %v", e.UID, err)
+			}
+		case e.Due != "":
+			due := e.timeType(e.Due, w.zones[e.TZID])
+			anchor, related = &due, RelatedEnd
+		}
+		w.writeUnknown(e)
+		w.writeAlarms(e, anchor, related)
+
 		w.contents.WriteString("END:VTODO" + newLine)
 	}
 
-	// Write the event content to the underlying writer
-	_, err := w.writer.Write([]byte(w.contents.String()))
+	// Write the event content to the underlying writer, folding long lines
+	_, err := w.writer.Write([]byte(foldLines(w.contents.String())))
 	return err
 }
 
+// writeParticipants writes the ORGANIZER and ATTENDEE properties of e.
+// The email of the writer is the organizer of entries without one.
+func (w *ICSWriter) writeParticipants(e *Entry) {
+	switch {
+	case e.Organizer != nil:
+		w.contents.WriteString(e.Organizer.OrganizerToICS() + newLine)
+	case w.Email != "":
+		w.contents.WriteString("ORGANIZER:" + mailto(w.Email) + newLine)
+	}
+
+	for _, a := range e.Attendees {
+		attendee, unmapped := a.ToICS(e.IsEvent)
+		for _, value := range unmapped {
+			w.warnf("Attendee %s of %s: dropping %s, it has no counterpart", a.Email, e.UID, value)
+		}
+		w.contents.WriteString(attendee + newLine)
+	}
+}
+
+// writeClassification writes CATEGORIES, RESOURCES, URL, GEO and
+// RELATED-TO
+func (w *ICSWriter) writeClassification(e *Entry) {
+	if len(e.Categories) > 0 {
+		w.contents.WriteString("CATEGORIES:" + escapeTextList(e.Categories) + newLine)
+	}
+	if len(e.Resources) > 0 {
+		w.contents.WriteString("RESOURCES:" + escapeTextList(e.Resources) + newLine)
+	}
+	if e.URL != "" {
+		w.contents.WriteString("URL:" + e.URL + newLine)
+	}
+	if e.Geo != "" {
+		w.contents.WriteString("GEO:" + e.Geo + newLine)
+	}
+	for _, r := range e.RelatedTo {
+		w.contents.WriteString("RELATED-TO")
+		switch r.Type {
+		case "", "PARENT":
+		case "CHILD", "SIBLING":
+			w.contents.WriteString(";RELTYPE=" + r.Type)
+		default:
+			w.warnf("Relation %s of %s has an unknown type %s, writing it as parent", r.UID, e.UID, r.Type)
+		}
+		w.contents.WriteString(":" + r.UID + newLine)
+	}
+}
+
+// writeAttachments writes the attachments of e, extracting inline data
+// to AttachmentDir if it is set
+func (w *ICSWriter) writeAttachments(e *Entry) error {
+	for i, a := range e.Attachments {
+		if w.AttachmentDir != "" {
+			extracted, err := a.Extract(w.AttachmentDir, e.UID, i+1)
+			if err != nil {
+				return err
+			}
+			a = extracted
+		}
+		w.contents.WriteString(a.ToICS() + newLine)
+	}
+	return nil
+}
+
+// writeUnknown writes the properties of e that are not converted, as the
+// Unknown policy of the writer says
+func (w *ICSWriter) writeUnknown(e *Entry) {
+	for _, p := range e.Unknown {
+		switch w.Unknown {
+		case KeepUnknown:
+			w.contents.WriteString(unknownToICS(p, p.Name) + newLine)
+		case PrefixUnknown:
+			w.contents.WriteString(unknownToICS(p, UnknownPrefix+p.Name) + newLine)
+		}
+	}
+}
+
+// writeMappedProperties writes STATUS, PRIORITY, CLASS and TRANSP with
+// their values mapped to RFC 5545. Values without a counterpart are kept
+// as X-VCS- properties.
+func (w *ICSWriter) writeMappedProperties(e *Entry) {
+	status, ok := MapStatus(e.Status, e.IsEvent)
+	w.writeMapped(e, "STATUS", e.Status, status, ok)
+
+	// Priorities only lack a counterpart when outside the scale
+	priority, ok := MapPriority(e.Priority, w.PriorityScale)
+	if !ok && e.Priority != "" {
+		scale := w.PriorityScale
+		if scale <= 0 {
+			scale = DefaultPriorityScale
+		}
+		w.warnf("PRIORITY %q of %s is outside the priority scale of 1 to %d, keeping it as X-VCS-PRIORITY", e.Priority, e.UID, scale)
+		w.keepUnmapped("PRIORITY", e.Priority)
+	} else {
+		w.writeMapped(e, "PRIORITY", e.Priority, priority, ok)
+	}
+
+	class, ok := MapClass(e.Class)
+	w.writeMapped(e, "CLASS", e.Class, class, ok)
+
+	transp, ok := MapTransp(e.Transp, e.IsEvent)
+	w.writeMapped(e, "TRANSP", e.Transp, transp, ok)
+
+	// A completed todo is done, whatever the percentage it gives
+	percent := e.Percent
+	if status, _ := MapStatus(e.Status, e.IsEvent); !e.IsEvent && (e.Completed != "" || status == "COMPLETED") {
+		percent = "100"
+	}
+	mapped, ok := MapPercentComplete(percent, e.IsEvent)
+	w.writeMapped(e, "PERCENT-COMPLETE", percent, mapped, ok)
+}
+
+func (w *ICSWriter) writeMapped(e *Entry, name, value, mapped string, ok bool) {
+	switch {
+	case value == "":
+	case ok:
+		w.contents.WriteString(name + ":" + mapped + newLine)
+	default:
+		comp := "VTODO"
+		if e.IsEvent {
+			comp = "VEVENT"
+		}
+		w.warnf("%s %q of %s has no %s counterpart, keeping it as X-VCS-%s", name, value, e.UID, comp, name)
+		w.keepUnmapped(name, value)
+	}
+}
+
+// writeDTStamp writes the DTSTAMP of e, which is always in UTC. A local
+// stamp is converted with the time zone of e, and replaced by the current
+// time when that zone is unknown.
+func (w *ICSWriter) writeDTStamp(e *Entry) {
+	stamp := FormatDate(w.Now())
+	if e.DTStamp != "" {
+		tz := w.zones[e.TZID]
+		utc, err := utcValue(e.DTStamp, tz)
+		switch {
+		case err != nil:
+			w.warnf("Ignoring DTSTAMP of %s: %v", e.UID, err)
+		case !strings.HasSuffix(e.DTStamp, "Z") && tz == nil:
+			w.warnf("DTSTAMP %s of %s is in an unknown local time, using the current time", e.DTStamp, e.UID)
+		default:
+			stamp = utc
+		}
+	}
+	w.contents.WriteString("DTSTAMP:" + stamp + newLine)
+}
+
+// keepUnmapped writes a value without a counterpart as an X-VCS- property
+func (w *ICSWriter) keepUnmapped(name, value string) {
+	w.contents.WriteString("X-VCS-" + name + ":" + EscapeText(value) + newLine)
+}
+
+// writeAlarms writes the alarms of e as VALARM components, triggered
+// relative to anchor, or at an absolute time if anchor is nil. Related
+// tells which end of the entry the anchor is.
+func (w *ICSWriter) writeAlarms(e *Entry, anchor *startType, related string) {
+	for _, a := range e.Alarms {
+		alarm := *a
+		var err error
+		if anchor != nil {
+			alarm.Related = related
+			alarm.Difference, err = anchor.trigger(a.RunTime)
+		} else {
+			alarm.At, err = utcValue(a.RunTime, w.zones[e.TZID])
+		}
+		if err != nil {
+			w.warnf("Ignoring alarm of %s: %v", e.UID, err)
+			continue
+		}
+		w.contents.WriteString(alarm.ToICS(e.Summary) + newLine)
+	}
+}
+
 // Close implements io.Closer interface and writes the calendar footer
 func (w *ICSWriter) Close() error {
 	if w.closed {
@@ -211,23 +501,11 @@ func (w *ICSWriter) Close() error {
 	return err
 }
 
-func checkForStartTimeIsZero(dtStart string) bool {
-	if dtStart == "" {
-		return false
-	}
-
-	dt, err := ParseDate(dtStart)
-	if err != nil {
-		return false
-	}
-
-	hour, min, _ := dt.Clock()
-	return hour == 0 && min == 0
-}
-
-func checkForSameStartAndEndTime(dtStart, dtEnd string) bool {
-	if dtStart == "" || dtEnd == "" {
-		return false
+// dateTimeValue formats the parameters and value of a date-time property.
+// Local times get the TZID parameter, UTC times are written as is.
+func dateTimeValue(value, tzid string) string {
+	if tzid == "" || strings.HasSuffix(value, "Z") {
+		return ":" + value
 	}
-	return dtStart == dtEnd
+	return ";TZID=" + tzid + ":" + value
 }